@@ -0,0 +1,203 @@
+package catalog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deadc0de6/gocatcli/internal/log"
+)
+
+const (
+	catalogPerm  = 0644
+	backupSuffix = ".bak."
+)
+
+// Backup a previous version of the catalog
+type Backup struct {
+	Index   int
+	Path    string
+	ModTime time.Time
+}
+
+// writeAtomic writes to a temporary file in the same directory
+// which is fsynced and then renamed over path
+func writeAtomic(path string, fill func(io.Writer) error) error {
+	perm := os.FileMode(catalogPerm)
+	if info, err := os.Stat(path); err == nil {
+		// keep the permission of the current catalog
+		perm = info.Mode().Perm()
+	}
+
+	dir := filepath.Dir(path)
+	fd, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := fd.Name()
+	log.Debugf("writing to temporary file \"%s\"", tmp)
+
+	err = fill(fd)
+	if err == nil {
+		err = fd.Sync()
+	}
+	cerr := fd.Close()
+	if err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		rerr := os.Remove(tmp)
+		if rerr != nil {
+			log.Error(rerr)
+		}
+		return err
+	}
+
+	syncDir(dir)
+	return nil
+}
+
+// syncDir ensures the rename is persisted
+func syncDir(dir string) {
+	fd, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	// not supported on all platforms
+	_ = fd.Sync()
+	err = fd.Close()
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+// copyAtomic atomically copies src to dst
+func copyAtomic(src string, dst string) error {
+	fd, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := fd.Close()
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+	return writeAtomic(dst, func(w io.Writer) error {
+		_, err := io.Copy(w, fd)
+		return err
+	})
+}
+
+// splitExt splits path into its base and its catalog
+// extensions (for example ".catalog.gz")
+func splitExt(path string) (string, string) {
+	ext := filepath.Ext(path)
+	if ext == gzipExt || ext == zstdExt {
+		ext = filepath.Ext(strings.TrimSuffix(path, ext)) + ext
+	}
+	return strings.TrimSuffix(path, ext), ext
+}
+
+// backupPath returns "<base>.bak.<idx><ext>", the extensions
+// are kept for the backup to be opened with the right backend
+func backupPath(path string, idx int) string {
+	base, ext := splitExt(path)
+	return fmt.Sprintf("%s%s%d%s", base, backupSuffix, idx, ext)
+}
+
+// isBackup returns true if path is the backup of a catalog
+func isBackup(path string) bool {
+	base, _ := splitExt(path)
+	idx := strings.LastIndex(base, backupSuffix)
+	if idx < 0 {
+		return false
+	}
+	_, err := strconv.Atoi(base[idx+len(backupSuffix):])
+	return err == nil
+}
+
+// rotateBackups shifts the existing backups and
+// saves the current catalog as the most recent one
+func rotateBackups(path string, keep int) error {
+	if keep < 1 {
+		return nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		// nothing to backup yet
+		return nil
+	}
+
+	// drop the backups we do not want to keep anymore
+	backups, err := listBackups(path)
+	if err != nil {
+		return err
+	}
+	for _, backup := range backups {
+		if backup.Index >= keep {
+			log.Debugf("removing old backup \"%s\"", backup.Path)
+			err = os.Remove(backup.Path)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// shift the others
+	for idx := keep - 1; idx > 0; idx-- {
+		src := backupPath(path, idx)
+		if _, err := os.Stat(src); err != nil {
+			continue
+		}
+		err = os.Rename(src, backupPath(path, idx+1))
+		if err != nil {
+			return err
+		}
+	}
+
+	dst := backupPath(path, 1)
+	log.Debugf("backup catalog to \"%s\"", dst)
+	return copyAtomic(path, dst)
+}
+
+// listBackups returns the existing backups of the catalog, most recent first
+func listBackups(path string) ([]*Backup, error) {
+	base, ext := splitExt(path)
+	matches, err := filepath.Glob(base + backupSuffix + "*" + ext)
+	if err != nil {
+		return nil, err
+	}
+
+	var backups []*Backup
+	for _, match := range matches {
+		num := strings.TrimSuffix(strings.TrimPrefix(match, base+backupSuffix), ext)
+		idx, err := strconv.Atoi(num)
+		if err != nil || idx < 1 {
+			continue
+		}
+		info, err := os.Stat(match)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, &Backup{
+			Index:   idx,
+			Path:    match,
+			ModTime: info.ModTime(),
+		})
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].Index < backups[j].Index
+	})
+	return backups, nil
+}
@@ -1,16 +1,24 @@
 package catalog
 
 import (
+	"fmt"
+	"os"
 	"path/filepath"
+	"strings"
 
+	"github.com/deadc0de6/gocatcli/internal/log"
 	"github.com/deadc0de6/gocatcli/internal/tree"
+
+	"github.com/mholt/archiver/v4"
 )
 
 const (
 	jsonExt    = ".json"
 	tomlExt    = ".toml"
-	sqliteExt  = ".sqlite" // TODO
+	sqliteExt  = ".sqlite"
 	catalogExt = ".catalog"
+	gzipExt    = ".gz"
+	zstdExt    = ".zst"
 )
 
 // Backend catalog backend
@@ -20,10 +28,19 @@ type Backend interface {
 	LoadTree(path string) (*tree.Tree, error)
 }
 
+// LazyBackend catalog backend able to load
+// nodes on demand instead of decoding the entire tree
+type LazyBackend interface {
+	LoadTreeLazy(path string) (*tree.Tree, error)
+	Close() error
+}
+
 // Catalog the file catalog
 type Catalog struct {
 	Path       string
 	TheBackend Backend
+	Backups    int // number of previous versions to keep
+	lock       *os.File
 }
 
 // Serialize the tree
@@ -33,31 +50,205 @@ func (c *Catalog) Serialize(t *tree.Tree) ([]byte, error) {
 
 // Save tree to file
 func (c *Catalog) Save(t *tree.Tree) error {
+	err := rotateBackups(c.Path, c.Backups)
+	if err != nil {
+		return fmt.Errorf("backup failed: %v", err)
+	}
 	return c.TheBackend.Save(c.Path, t)
 }
 
-// LoadTree from file
+// GetBackups returns the previous versions of the catalog, most recent first
+func (c *Catalog) GetBackups() ([]*Backup, error) {
+	return listBackups(c.Path)
+}
+
+// Restore rolls back the catalog to the backup with index idx,
+// the current catalog becomes the most recent backup if backups
+// are enabled. A restore never removes any existing backup
+func (c *Catalog) Restore(idx int) error {
+	src := backupPath(c.Path, idx)
+	if _, err := os.Stat(src); err != nil {
+		return fmt.Errorf("no such backup %d", idx)
+	}
+	backups, err := listBackups(c.Path)
+	if err != nil {
+		return err
+	}
+
+	// keep a copy before rotating the backups
+	tmp := c.Path + ".restore"
+	err = copyAtomic(src, tmp)
+	if err != nil {
+		return err
+	}
+	if c.Backups > 0 {
+		keep := c.Backups
+		if len(backups) > 0 {
+			keep = max(keep, backups[len(backups)-1].Index+1)
+		}
+		err = rotateBackups(c.Path, keep)
+	}
+	if err == nil {
+		err = os.Rename(tmp, c.Path)
+	}
+	if err != nil {
+		rerr := os.Remove(tmp)
+		if rerr != nil {
+			log.Error(rerr)
+		}
+		return err
+	}
+	return nil
+}
+
+// LoadTree from file, catalogs with an older
+// schema are upgraded in memory
 func (c *Catalog) LoadTree() (*tree.Tree, error) {
-	return c.TheBackend.LoadTree(c.Path)
+	t, err := c.TheBackend.LoadTree(c.Path)
+	if err != nil {
+		return nil, err
+	}
+	_, err = migrate(t)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
 }
 
-// NewCatalog creates a new catalog
-func NewCatalog(path string) *Catalog {
+// LoadTreeLazy loads the tree from file, nodes are only loaded
+// when accessed if the backend supports it.
+// The returned tree is read-only and the catalog must be closed
+// once done with it
+func (c *Catalog) LoadTreeLazy() (*tree.Tree, error) {
+	lazy, ok := c.TheBackend.(LazyBackend)
+	if !ok {
+		return c.LoadTree()
+	}
+	t, err := lazy.LoadTreeLazy(c.Path)
+	if err != nil {
+		return nil, err
+	}
+	err = checkSchema(t)
+	if err != nil {
+		return nil, err
+	}
+	if needsMigration(t) {
+		// migrations need the entire tree
+		log.Debugf("catalog schema %d is outdated, loading entire tree", t.Schema)
+		err = lazy.Close()
+		if err != nil {
+			return nil, err
+		}
+		return c.LoadTree()
+	}
+	return t, nil
+}
+
+// Upgrade migrates the catalog to the current schema
+// and saves it, returns the migrations applied
+func (c *Catalog) Upgrade() ([]*Migration, error) {
+	t, err := c.TheBackend.LoadTree(c.Path)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := migrate(t)
+	if err != nil {
+		return nil, err
+	}
+	if len(applied) < 1 {
+		return nil, nil
+	}
+	return applied, c.Save(t)
+}
+
+// Close releases the resources held by the catalog
+func (c *Catalog) Close() error {
+	lazy, ok := c.TheBackend.(LazyBackend)
+	if !ok {
+		return nil
+	}
+	return lazy.Close()
+}
+
+// IsCatalogFile returns true if path has a catalog extension
+func IsCatalogFile(path string) bool {
+	ext := filepath.Ext(path)
+	if ext == gzipExt || ext == zstdExt {
+		ext = filepath.Ext(strings.TrimSuffix(path, ext))
+	}
+	switch ext {
+	case catalogExt, jsonExt, tomlExt, sqliteExt:
+		return true
+	}
+	return false
+}
+
+// Name returns the catalog file name without its extensions
+func Name(path string) string {
+	name := filepath.Base(path)
+	for IsCatalogFile(name) {
+		name = strings.TrimSuffix(name, filepath.Ext(name))
+	}
+	return name
+}
+
+// FindCatalogs returns the catalog files found in dir
+func FindCatalogs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, entry := range entries {
+		if entry.IsDir() || !IsCatalogFile(entry.Name()) || isBackup(entry.Name()) {
+			continue
+		}
+		paths = append(paths, filepath.Join(dir, entry.Name()))
+	}
+	return paths, nil
+}
+
+// NewCatalog creates a new catalog, the backend
+// is selected by the extensions of path
+func NewCatalog(path string) (*Catalog, error) {
 	var b Backend
 	ext := filepath.Ext(path)
+
+	// compressed catalog
+	var compression archiver.Compression
+	switch ext {
+	case gzipExt:
+		compression = archiver.Gz{}
+	case zstdExt:
+		compression = archiver.Zstd{}
+	}
+	if compression != nil {
+		ext = filepath.Ext(strings.TrimSuffix(path, ext))
+	}
+
 	switch ext {
 	case catalogExt, jsonExt:
 		b = NewJSONBackend()
 	case tomlExt:
 		b = NewTOMLBackend()
+	case sqliteExt:
+		b = NewSQLiteBackend()
 	default:
 		// defaults to json
 		b = NewJSONBackend()
 	}
 
+	if compression != nil {
+		stream, ok := b.(StreamBackend)
+		if !ok {
+			return nil, fmt.Errorf("%s catalog cannot be compressed: %s", ext, path)
+		}
+		b = NewCompressedBackend(stream, compression)
+	}
+
 	c := Catalog{
 		Path:       path,
 		TheBackend: b,
 	}
-	return &c
+	return &c, nil
 }
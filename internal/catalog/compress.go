@@ -0,0 +1,113 @@
+package catalog
+
+import (
+	"bytes"
+	"io"
+	"os"
+
+	"github.com/deadc0de6/gocatcli/internal/log"
+	"github.com/deadc0de6/gocatcli/internal/tree"
+
+	"github.com/mholt/archiver/v4"
+)
+
+// StreamBackend backend able to (de)serialize the tree from/to a stream
+type StreamBackend interface {
+	Backend
+	Encode(w io.Writer, t *tree.Tree) error
+	Decode(r io.Reader) (*tree.Tree, error)
+}
+
+// CompressedBackend compresses/decompresses
+// on the fly around a stream backend
+type CompressedBackend struct {
+	inner       StreamBackend
+	compression archiver.Compression
+}
+
+// Serialize gets the compressed tree
+func (b *CompressedBackend) Serialize(t *tree.Tree) ([]byte, error) {
+	content, err := b.inner.Serialize(t)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	err = b.compress(buf, func(w io.Writer) error {
+		_, err := w.Write(content)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// compress what fill writes into w
+func (b *CompressedBackend) compress(w io.Writer, fill func(io.Writer) error) error {
+	cw, err := b.compression.OpenWriter(w)
+	if err != nil {
+		return err
+	}
+	err = fill(cw)
+	if err != nil {
+		cerr := cw.Close()
+		if cerr != nil {
+			log.Error(cerr)
+		}
+		return err
+	}
+	return cw.Close()
+}
+
+// Save saves a compressed tree
+func (b *CompressedBackend) Save(path string, t *tree.Tree) error {
+	log.Debugf("write %s compressed tree to \"%s\"...", b.compression.Name(), path)
+	err := writeAtomic(path, func(w io.Writer) error {
+		return b.compress(w, func(cw io.Writer) error {
+			return b.inner.Encode(cw, t)
+		})
+	})
+	if err != nil {
+		return err
+	}
+	log.Debugf("tree saved to \"%s\"", path)
+	return nil
+}
+
+// LoadTree loads a compressed tree
+func (b *CompressedBackend) LoadTree(path string) (*tree.Tree, error) {
+	log.Debugf("loading %s compressed catalog from %s", b.compression.Name(), path)
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := fd.Close()
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+
+	rd, err := b.compression.OpenReader(fd)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := rd.Close()
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+
+	return b.inner.Decode(rd)
+}
+
+// NewCompressedBackend creates a new compressed backend
+func NewCompressedBackend(inner StreamBackend, compression archiver.Compression) *CompressedBackend {
+	b := &CompressedBackend{
+		inner:       inner,
+		compression: compression,
+	}
+	return b
+}
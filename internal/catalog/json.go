@@ -2,6 +2,7 @@ package catalog
 
 import (
 	"encoding/json"
+	"io"
 	"os"
 	"time"
 
@@ -35,6 +36,31 @@ func (b *JSONBackend) Serialize(t *tree.Tree) ([]byte, error) {
 	return content, err
 }
 
+// Encode writes the tree as json to w
+func (b *JSONBackend) Encode(w io.Writer, t *tree.Tree) error {
+	t.Updated = time.Now().Unix()
+
+	enc := json.NewEncoder(w)
+	if jsonIndent {
+		enc.SetIndent("", "  ")
+	}
+	err := enc.Encode(t)
+	if err != nil {
+		log.Debugf("marshal failed: %v", err)
+	}
+	return err
+}
+
+// Decode reads a json tree from r
+func (b *JSONBackend) Decode(r io.Reader) (*tree.Tree, error) {
+	var tree tree.Tree
+	err := json.NewDecoder(r).Decode(&tree)
+	if err != nil {
+		return nil, err
+	}
+	return &tree, nil
+}
+
 // Save saves a tree to json
 func (b *JSONBackend) Save(path string, t *tree.Tree) error {
 	log.Debug("serialize tree...")
@@ -44,7 +70,10 @@ func (b *JSONBackend) Save(path string, t *tree.Tree) error {
 	}
 
 	log.Debugf("write tree to \"%s\"...", path)
-	err = os.WriteFile(path, content, os.ModePerm)
+	err = writeAtomic(path, func(w io.Writer) error {
+		_, err := w.Write(content)
+		return err
+	})
 	if err != nil {
 		return err
 	}
@@ -66,13 +95,7 @@ func (b *JSONBackend) LoadTree(path string) (*tree.Tree, error) {
 		}
 	}()
 
-	var tree tree.Tree
-	err = json.NewDecoder(fd).Decode(&tree)
-	if err != nil {
-		return nil, err
-	}
-
-	return &tree, nil
+	return b.Decode(fd)
 }
 
 // NewJSONBackend creates a new json backend
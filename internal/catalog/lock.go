@@ -0,0 +1,108 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"syscall"
+
+	"github.com/deadc0de6/gocatcli/internal/log"
+)
+
+const (
+	lockSuffix = ".lock"
+	lockPerm   = 0600
+)
+
+// Lock takes an advisory lock on the catalog, exclusive for
+// commands modifying the catalog, shared for the others.
+// It blocks until the lock is acquired. Commands only reading
+// the catalog run unlocked when the lock file cannot be
+// created (read-only media for example)
+func (c *Catalog) Lock(exclusive bool) error {
+	path := c.Path + lockSuffix
+	for {
+		fd, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, lockPerm)
+		if err != nil {
+			if !exclusive && (errors.Is(err, fs.ErrPermission) || errors.Is(err, syscall.EROFS)) {
+				log.Debugf("catalog not locked: %v", err)
+				return nil
+			}
+			return fmt.Errorf("cannot lock catalog: %v", err)
+		}
+
+		locked, err := tryLockFile(fd, exclusive)
+		if err == nil && !locked {
+			log.Warnf("catalog \"%s\" is in use, waiting...", c.Path)
+			err = lockFile(fd, exclusive)
+		}
+		if err != nil {
+			closeLock(fd)
+			return fmt.Errorf("cannot lock catalog: %v", err)
+		}
+
+		// the lock file is removed by its last owner,
+		// lock the new one if it was removed while waiting
+		if isLockFile(fd, path) {
+			c.lock = fd
+			log.Debugf("catalog locked (exclusive:%v) with \"%s\"", exclusive, path)
+			return nil
+		}
+		log.Debugf("lock file \"%s\" was removed, retrying", path)
+		err = unlockFile(fd)
+		if err != nil {
+			log.Error(err)
+		}
+		closeLock(fd)
+	}
+}
+
+// Unlock releases the lock taken with Lock,
+// the lock file is removed if no one else holds it
+func (c *Catalog) Unlock() error {
+	if c.lock == nil {
+		return nil
+	}
+	fd := c.lock
+	c.lock = nil
+
+	if lockRemovable {
+		// only possible with an exclusive lock
+		locked, err := tryLockFile(fd, true)
+		if err == nil && locked {
+			err = os.Remove(fd.Name())
+			if err != nil {
+				log.Debugf("cannot remove lock file: %v", err)
+			}
+		}
+	}
+
+	err := unlockFile(fd)
+	closeLock(fd)
+	if err != nil {
+		return err
+	}
+	log.Debugf("catalog unlocked")
+	return nil
+}
+
+// isLockFile returns true if fd is still the file at path
+func isLockFile(fd *os.File, path string) bool {
+	locked, err := fd.Stat()
+	if err != nil {
+		return false
+	}
+	current, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(locked, current)
+}
+
+func closeLock(fd *os.File) {
+	err := fd.Close()
+	if err != nil {
+		log.Error(err)
+	}
+}
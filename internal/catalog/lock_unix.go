@@ -0,0 +1,42 @@
+//go:build !windows
+// +build !windows
+
+package catalog
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// the lock file can be removed while others wait for it
+const lockRemovable = true
+
+func lockFlag(exclusive bool) int {
+	if exclusive {
+		return syscall.LOCK_EX
+	}
+	return syscall.LOCK_SH
+}
+
+// tryLockFile returns false if the file is locked by someone else
+func tryLockFile(fd *os.File, exclusive bool) (bool, error) {
+	err := syscall.Flock(int(fd.Fd()), lockFlag(exclusive)|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func lockFile(fd *os.File, exclusive bool) error {
+	for {
+		err := syscall.Flock(int(fd.Fd()), lockFlag(exclusive))
+		if !errors.Is(err, syscall.EINTR) {
+			return err
+		}
+	}
+}
+
+func unlockFile(fd *os.File) error {
+	return syscall.Flock(int(fd.Fd()), syscall.LOCK_UN)
+}
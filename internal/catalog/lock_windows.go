@@ -0,0 +1,41 @@
+//go:build windows
+// +build windows
+
+package catalog
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// open files cannot be removed
+const lockRemovable = false
+
+func lockFlags(exclusive bool) uint32 {
+	if exclusive {
+		return windows.LOCKFILE_EXCLUSIVE_LOCK
+	}
+	return 0
+}
+
+// tryLockFile returns false if the file is locked by someone else
+func tryLockFile(fd *os.File, exclusive bool) (bool, error) {
+	ol := new(windows.Overlapped)
+	err := windows.LockFileEx(windows.Handle(fd.Fd()), lockFlags(exclusive)|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, ol)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func lockFile(fd *os.File, exclusive bool) error {
+	ol := new(windows.Overlapped)
+	return windows.LockFileEx(windows.Handle(fd.Fd()), lockFlags(exclusive), 0, 1, 0, ol)
+}
+
+func unlockFile(fd *os.File) error {
+	ol := new(windows.Overlapped)
+	return windows.UnlockFileEx(windows.Handle(fd.Fd()), 0, 1, 0, ol)
+}
@@ -0,0 +1,164 @@
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/deadc0de6/gocatcli/internal/helpers"
+	"github.com/deadc0de6/gocatcli/internal/log"
+	"github.com/deadc0de6/gocatcli/internal/node"
+	"github.com/deadc0de6/gocatcli/internal/tree"
+)
+
+var (
+	// ErrSchemaTooRecent the catalog was written by a more recent gocatcli
+	ErrSchemaTooRecent = errors.New("catalog schema is more recent than supported")
+
+	// migrations registry, ordered by schema version.
+	// A migration modifying the nodes of a storage must
+	// mark it dirty for the sqlite backend to rewrite it
+	migrations = []*Migration{
+		{
+			From:        0,
+			Description: "add schema version to catalog",
+			Migrate:     func(*tree.Tree) error { return nil },
+		},
+		{
+			From:        1,
+			Description: "derive stable ids for storages and entries",
+			Migrate:     migrateStableIDs,
+		},
+		{
+			From:        2,
+			Description: "record the checksum algorithm",
+			Migrate:     migrateChecksumAlgo,
+		},
+		{
+			From:        3,
+			Description: "storages may belong to a collection",
+			Migrate:     func(*tree.Tree) error { return nil },
+		},
+		{
+			From:        4,
+			Description: "entries may have tags and a note",
+			Migrate:     func(*tree.Tree) error { return nil },
+		},
+		{
+			From:        5,
+			Description: "entries may have extracted metadata",
+			Migrate:     func(*tree.Tree) error { return nil },
+		},
+	}
+)
+
+// Migration upgrades a tree from schema From to From+1
+type Migration struct {
+	From        int
+	Description string
+	Migrate     func(t *tree.Tree) error
+}
+
+// checkSchema ensures the tree schema can be handled
+func checkSchema(t *tree.Tree) error {
+	if t.Schema > tree.SchemaVersion {
+		return fmt.Errorf("%w (catalog schema %d, supported %d), upgrade gocatcli", ErrSchemaTooRecent, t.Schema, tree.SchemaVersion)
+	}
+	return nil
+}
+
+// needsMigration returns true if the tree schema is outdated
+func needsMigration(t *tree.Tree) bool {
+	return t.Schema < tree.SchemaVersion
+}
+
+// migrate upgrades the tree to the current schema
+// and returns the migrations applied
+func migrate(t *tree.Tree) ([]*Migration, error) {
+	err := checkSchema(t)
+	if err != nil {
+		return nil, err
+	}
+
+	var applied []*Migration
+	for _, m := range migrations {
+		if m.From != t.Schema {
+			continue
+		}
+		log.Debugf("migrating catalog schema %d to %d: %s", m.From, m.From+1, m.Description)
+		err := m.Migrate(t)
+		if err != nil {
+			return applied, fmt.Errorf("migration of schema %d failed: %v", m.From, err)
+		}
+		t.Schema = m.From + 1
+		applied = append(applied, m)
+	}
+
+	if t.Schema != tree.SchemaVersion {
+		return applied, fmt.Errorf("no migration from catalog schema %d", t.Schema)
+	}
+	return applied, nil
+}
+
+// storage ids are derived from their name
+// and entries ids from their storage and path
+func migrateStableIDs(t *tree.Tree) error {
+	for _, storage := range t.Storages {
+		storage.ID = node.DeriveStorageID(storage.Name)
+		for _, child := range storage.Children {
+			setStableIDs(storage.ID, child)
+		}
+		storage.SetDirty(true)
+	}
+	return nil
+}
+
+func setStableIDs(storageID int, n *node.FileNode) {
+	n.StorageID = storageID
+	n.ID = node.DeriveFileID(storageID, n.GetPath())
+	for _, child := range n.Children {
+		setStableIDs(storageID, child)
+	}
+}
+
+// checksums were md5 digests
+// serialized under the "md5" key
+func migrateChecksumAlgo(t *tree.Tree) error {
+	for _, storage := range t.Storages {
+		var found, changed bool
+		for _, child := range storage.Children {
+			f, c := setChecksumAlgo(child)
+			found = found || f
+			changed = changed || c
+		}
+		if found {
+			storage.ChecksumAlgo = helpers.DefaultChecksumAlgo
+		}
+		if changed {
+			storage.SetDirty(true)
+		}
+	}
+	return nil
+}
+
+// returns whether a checksum was found and
+// whether a node was changed under n
+func setChecksumAlgo(n *node.FileNode) (bool, bool) {
+	var found, changed bool
+	if len(n.MD5) > 0 {
+		n.Checksum = n.MD5
+		n.MD5 = ""
+		changed = true
+	}
+	if len(n.Checksum) > 0 && !strings.Contains(n.Checksum, ":") {
+		n.Checksum = helpers.FormatChecksum(helpers.DefaultChecksumAlgo, n.Checksum)
+		changed = true
+	}
+	found = len(n.Checksum) > 0
+	for _, child := range n.Children {
+		f, c := setChecksumAlgo(child)
+		found = found || f
+		changed = changed || c
+	}
+	return found, changed
+}
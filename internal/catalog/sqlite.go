@@ -0,0 +1,584 @@
+package catalog
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/deadc0de6/gocatcli/internal/log"
+	"github.com/deadc0de6/gocatcli/internal/node"
+	"github.com/deadc0de6/gocatcli/internal/tree"
+
+	// registers the "sqlite" database/sql driver
+	_ "modernc.org/sqlite"
+)
+
+const (
+	sqliteDriver = "sqlite"
+	sqliteSchema = `
+CREATE TABLE IF NOT EXISTS info (
+	key   TEXT PRIMARY KEY,
+	value TEXT
+);
+CREATE TABLE IF NOT EXISTS storages (
+	id       INTEGER PRIMARY KEY,
+	position INTEGER,
+	name     TEXT,
+	path     TEXT,
+	size     INTEGER,
+	free     INTEGER,
+	total    INTEGER,
+	ts       INTEGER,
+	type     TEXT,
+	tags     TEXT,
+	meta     TEXT,
+	nb_files INTEGER,
+	checksum_algo TEXT DEFAULT '',
+	collection TEXT DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS nodes (
+	rowid      INTEGER PRIMARY KEY,
+	storage_id INTEGER,
+	parent     INTEGER,
+	id         TEXT,
+	name       TEXT,
+	relpath    TEXT,
+	checksum   TEXT,
+	filetype   TEXT,
+	size       INTEGER,
+	maccess    INTEGER,
+	ts         INTEGER,
+	mode       TEXT,
+	mime       TEXT,
+	extra      TEXT,
+	tags       TEXT DEFAULT '',
+	note       TEXT DEFAULT '',
+	metadata   TEXT DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS nodes_parent ON nodes (storage_id, parent);
+`
+	sqliteNodeColumns = "rowid, parent, id, name, relpath, checksum, filetype, size, maccess, ts, mode, mime, extra, tags, note, metadata"
+	sqliteInsertNode  = "INSERT INTO nodes (storage_id, parent, id, name, relpath, checksum, filetype, size, maccess, ts, mode, mime, extra, tags, note, metadata) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+)
+
+// columns added to the tables after their creation
+var sqliteAddedColumns = []struct {
+	table string
+	name  string
+	def   string
+}{
+	{"storages", "checksum_algo", "TEXT DEFAULT ''"},
+	{"storages", "collection", "TEXT DEFAULT ''"},
+	{"nodes", "tags", "TEXT DEFAULT ''"},
+	{"nodes", "note", "TEXT DEFAULT ''"},
+	{"nodes", "metadata", "TEXT DEFAULT ''"},
+}
+
+// SQLiteBackend the sqlite backend
+// nodes are stored as rows keyed by storage id and parent
+// and only the storages that changed are re-written on save
+type SQLiteBackend struct {
+	// storages loaded from (or saved to) the database
+	// and thus in sync with it unless marked dirty
+	clean map[*node.StorageNode]bool
+	// database kept opened when nodes are loaded on demand
+	db *sql.DB
+}
+
+func openSQLite(path string) (*sql.DB, error) {
+	db, err := sql.Open(sqliteDriver, path)
+	if err != nil {
+		return nil, err
+	}
+	_, err = db.Exec(sqliteSchema)
+	if err == nil {
+		err = addSQLiteColumns(db)
+	}
+	if err != nil {
+		closeSQLite(db)
+		return nil, err
+	}
+	return db, nil
+}
+
+// addSQLiteColumns adds the columns missing
+// from databases created by older versions
+func addSQLiteColumns(db *sql.DB) error {
+	for _, col := range sqliteAddedColumns {
+		var cnt int
+		err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?", col.table, col.name).Scan(&cnt)
+		if err != nil {
+			return err
+		}
+		if cnt > 0 {
+			continue
+		}
+		log.Debugf("adding column %s to sqlite table %s", col.name, col.table)
+		_, err = db.Exec(fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", col.table, col.name, col.def))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func closeSQLite(db *sql.DB) {
+	err := db.Close()
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+// Serialize gets the tree as a sqlite database
+func (b *SQLiteBackend) Serialize(t *tree.Tree) ([]byte, error) {
+	fd, err := os.CreateTemp("", "gocatcli-*.sqlite")
+	if err != nil {
+		return nil, err
+	}
+	tmp := fd.Name()
+	defer func() {
+		err := os.Remove(tmp)
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+	err = fd.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	// use a fresh backend to write everything
+	err = NewSQLiteBackend().Save(tmp, t)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(tmp)
+}
+
+// Save saves a tree to sqlite
+func (b *SQLiteBackend) Save(path string, t *tree.Tree) error {
+	if b.db != nil {
+		return fmt.Errorf("catalog loaded on demand is read-only")
+	}
+	t.Updated = time.Now().Unix()
+
+	log.Debugf("write tree to \"%s\"...", path)
+	db, err := openSQLite(path)
+	if err != nil {
+		return err
+	}
+	defer closeSQLite(db)
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	err = b.saveTx(tx, t)
+	if err != nil {
+		rerr := tx.Rollback()
+		if rerr != nil {
+			log.Error(rerr)
+		}
+		return err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	for _, storage := range t.Storages {
+		storage.SetDirty(false)
+		b.clean[storage] = true
+	}
+	log.Debugf("tree saved to \"%s\"", path)
+	return nil
+}
+
+func (b *SQLiteBackend) saveTx(tx *sql.Tx, t *tree.Tree) error {
+	// tree info
+	infos := map[string]string{
+		"tool":    t.Tool,
+		"version": t.Version,
+		"schema":  strconv.Itoa(t.Schema),
+		"created": strconv.FormatInt(t.Created, 10),
+		"updated": strconv.FormatInt(t.Updated, 10),
+		"note":    t.Note,
+	}
+	for key, value := range infos {
+		_, err := tx.Exec("INSERT OR REPLACE INTO info (key, value) VALUES (?, ?)", key, value)
+		if err != nil {
+			return err
+		}
+	}
+
+	// storages already in the database
+	existing := make(map[int]bool)
+	rows, err := tx.Query("SELECT id FROM storages")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var id int
+		err = rows.Scan(&id)
+		if err != nil {
+			return err
+		}
+		existing[id] = true
+	}
+	err = rows.Close()
+	if err != nil {
+		return err
+	}
+
+	insertNode, err := tx.Prepare(sqliteInsertNode)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := insertNode.Close()
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+
+	for idx, storage := range t.Storages {
+		tags, err := json.Marshal(storage.Tags)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT OR REPLACE INTO storages
+			(id, position, name, path, size, free, total, ts, type, tags, meta, nb_files, checksum_algo, collection)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			storage.ID, idx, storage.Name, storage.Path, int64(storage.Size), int64(storage.Free),
+			int64(storage.Total), storage.IndexedAt, string(storage.Type), string(tags), storage.Meta,
+			int64(storage.TotalFiles), storage.ChecksumAlgo, storage.Collection)
+		if err != nil {
+			return err
+		}
+
+		inSync := existing[storage.ID] && b.clean[storage] && !storage.IsDirty()
+		delete(existing, storage.ID)
+		if inSync {
+			log.Debugf("storage \"%s\" unchanged, skipping its nodes", storage.Name)
+			continue
+		}
+
+		log.Debugf("writing nodes of storage \"%s\"", storage.Name)
+		_, err = tx.Exec("DELETE FROM nodes WHERE storage_id = ?", storage.ID)
+		if err != nil {
+			return err
+		}
+		for _, child := range storage.Children {
+			err = insertNodeRec(insertNode, storage.ID, 0, child)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// remove the storages that are gone
+	for id := range existing {
+		log.Debugf("removing storage %d from database", id)
+		_, err = tx.Exec("DELETE FROM nodes WHERE storage_id = ?", id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("DELETE FROM storages WHERE id = ?", id)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recursively insert a node and its children
+func insertNodeRec(stmt *sql.Stmt, storageID int, parent int64, n *node.FileNode) error {
+	var tags string
+	if len(n.Tags) > 0 {
+		content, err := json.Marshal(n.Tags)
+		if err != nil {
+			return err
+		}
+		tags = string(content)
+	}
+	var metadata string
+	if len(n.Metadata) > 0 {
+		content, err := json.Marshal(n.Metadata)
+		if err != nil {
+			return err
+		}
+		metadata = string(content)
+	}
+	res, err := stmt.Exec(storageID, parent, n.ID, n.Name, n.RelPath, n.Checksum, string(n.Type),
+		int64(n.Size), n.Maccess, n.IndexedAt, n.Mode, n.Mime, n.Extra, tags, n.Note, metadata)
+	if err != nil {
+		return err
+	}
+	rowid, err := res.LastInsertId()
+	if err != nil {
+		return err
+	}
+	for _, child := range n.Children {
+		err = insertNodeRec(stmt, storageID, rowid, child)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadTree loads a tree from sqlite
+func (b *SQLiteBackend) LoadTree(path string) (*tree.Tree, error) {
+	log.Debugf("loading catalog from %s", path)
+	if _, err := os.Stat(path); err != nil {
+		// do not let sqlite create an empty database
+		return nil, err
+	}
+	db, err := openSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+	defer closeSQLite(db)
+
+	t, err := loadSQLiteInfo(db)
+	if err != nil {
+		return nil, err
+	}
+
+	t.Storages, err = loadSQLiteStorages(db)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, storage := range t.Storages {
+		err = loadSQLiteNodes(db, storage)
+		if err != nil {
+			return nil, err
+		}
+		b.clean[storage] = true
+	}
+	return t, nil
+}
+
+func loadSQLiteInfo(db *sql.DB) (*tree.Tree, error) {
+	var t tree.Tree
+	rows, err := db.Query("SELECT key, value FROM info")
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+	for rows.Next() {
+		var key, value string
+		err = rows.Scan(&key, &value)
+		if err != nil {
+			return nil, err
+		}
+		switch key {
+		case "tool":
+			t.Tool = value
+		case "version":
+			t.Version = value
+		case "schema":
+			t.Schema, _ = strconv.Atoi(value)
+		case "created":
+			t.Created, _ = strconv.ParseInt(value, 10, 64)
+		case "updated":
+			t.Updated, _ = strconv.ParseInt(value, 10, 64)
+		case "note":
+			t.Note = value
+		}
+	}
+	return &t, rows.Err()
+}
+
+func loadSQLiteStorages(db *sql.DB) ([]*node.StorageNode, error) {
+	var storages []*node.StorageNode
+	rows, err := db.Query(`SELECT id, name, path, size, free, total, ts, type, tags, meta, nb_files, checksum_algo, collection
+		FROM storages ORDER BY position`)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+	for rows.Next() {
+		var storage node.StorageNode
+		var size, free, total, nbFiles int64
+		var typ, tags string
+		err = rows.Scan(&storage.ID, &storage.Name, &storage.Path, &size, &free, &total,
+			&storage.IndexedAt, &typ, &tags, &storage.Meta, &nbFiles, &storage.ChecksumAlgo, &storage.Collection)
+		if err != nil {
+			return nil, err
+		}
+		storage.Size = uint64(size)
+		storage.Free = uint64(free)
+		storage.Total = uint64(total)
+		storage.TotalFiles = uint64(nbFiles)
+		storage.Type = node.FileType(typ)
+		err = json.Unmarshal([]byte(tags), &storage.Tags)
+		if err != nil {
+			return nil, fmt.Errorf("bad tags for storage \"%s\": %v", storage.Name, err)
+		}
+		storages = append(storages, &storage)
+	}
+	return storages, rows.Err()
+}
+
+func scanSQLiteNode(rows *sql.Rows, storageID int) (*node.FileNode, int64, int64, error) {
+	var n node.FileNode
+	var rowid, parent, size int64
+	var typ, tags, metadata string
+	err := rows.Scan(&rowid, &parent, &n.ID, &n.Name, &n.RelPath, &n.Checksum, &typ,
+		&size, &n.Maccess, &n.IndexedAt, &n.Mode, &n.Mime, &n.Extra, &tags, &n.Note, &metadata)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if len(tags) > 0 {
+		err = json.Unmarshal([]byte(tags), &n.Tags)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("bad tags for \"%s\": %v", n.Name, err)
+		}
+	}
+	if len(metadata) > 0 {
+		err = json.Unmarshal([]byte(metadata), &n.Metadata)
+		if err != nil {
+			return nil, 0, 0, fmt.Errorf("bad metadata for \"%s\": %v", n.Name, err)
+		}
+	}
+	n.Size = uint64(size)
+	n.Type = node.FileType(typ)
+	n.StorageID = storageID
+	return &n, rowid, parent, nil
+}
+
+func loadSQLiteNodes(db *sql.DB, storage *node.StorageNode) error {
+	// parents are always inserted before their children
+	rows, err := db.Query("SELECT "+sqliteNodeColumns+" FROM nodes WHERE storage_id = ? ORDER BY rowid", storage.ID)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+
+	nodes := make(map[int64]*node.FileNode)
+	for rows.Next() {
+		n, rowid, parent, err := scanSQLiteNode(rows, storage.ID)
+		if err != nil {
+			return err
+		}
+		nodes[rowid] = n
+		if parent == 0 {
+			storage.AddChild(n)
+			continue
+		}
+		p, ok := nodes[parent]
+		if !ok {
+			return fmt.Errorf("orphan node \"%s\" in storage \"%s\"", n.Name, storage.Name)
+		}
+		p.AddChild(n)
+	}
+	return rows.Err()
+}
+
+// LoadTreeLazy loads the storages from sqlite, their nodes
+// are queried from the database when first accessed
+func (b *SQLiteBackend) LoadTreeLazy(path string) (*tree.Tree, error) {
+	log.Debugf("loading catalog lazily from %s", path)
+	if _, err := os.Stat(path); err != nil {
+		// do not let sqlite create an empty database
+		return nil, err
+	}
+	db, err := openSQLite(path)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := loadSQLiteInfo(db)
+	if err != nil {
+		closeSQLite(db)
+		return nil, err
+	}
+
+	t.Storages, err = loadSQLiteStorages(db)
+	if err != nil {
+		closeSQLite(db)
+		return nil, err
+	}
+
+	b.db = db
+	for _, storage := range t.Storages {
+		storage.SetChildrenLoader(b.childrenLoader(storage, 0))
+	}
+	return t, nil
+}
+
+// returns a loader querying the children of parent
+func (b *SQLiteBackend) childrenLoader(storage *node.StorageNode, parent int64) node.ChildrenLoader {
+	return func() []*node.FileNode {
+		children, err := b.queryChildren(storage, parent)
+		if err != nil {
+			log.Errorf("loading children of storage \"%s\" failed: %v", storage.Name, err)
+			return nil
+		}
+		return children
+	}
+}
+
+func (b *SQLiteBackend) queryChildren(storage *node.StorageNode, parent int64) ([]*node.FileNode, error) {
+	rows, err := b.db.Query("SELECT "+sqliteNodeColumns+" FROM nodes WHERE storage_id = ? AND parent = ?", storage.ID, parent)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+
+	var children []*node.FileNode
+	for rows.Next() {
+		n, rowid, _, err := scanSQLiteNode(rows, storage.ID)
+		if err != nil {
+			return nil, err
+		}
+		if n.GetType() != node.FileTypeFile {
+			n.SetChildrenLoader(b.childrenLoader(storage, rowid))
+		}
+		children = append(children, n)
+	}
+	return children, rows.Err()
+}
+
+// Close closes the database opened for on demand loading
+func (b *SQLiteBackend) Close() error {
+	if b.db == nil {
+		return nil
+	}
+	err := b.db.Close()
+	b.db = nil
+	return err
+}
+
+// NewSQLiteBackend creates a new sqlite backend
+func NewSQLiteBackend() *SQLiteBackend {
+	b := &SQLiteBackend{
+		clean: make(map[*node.StorageNode]bool),
+	}
+	return b
+}
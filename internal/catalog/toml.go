@@ -2,7 +2,7 @@ package catalog
 
 import (
 	"bytes"
-	"os"
+	"io"
 	"time"
 
 	"github.com/deadc0de6/gocatcli/internal/log"
@@ -27,6 +27,27 @@ func (b *TOMLBackend) Serialize(t *tree.Tree) ([]byte, error) {
 	return buf.Bytes(), err
 }
 
+// Encode writes the tree as toml to w
+func (b *TOMLBackend) Encode(w io.Writer, t *tree.Tree) error {
+	t.Updated = time.Now().Unix()
+
+	err := toml.NewEncoder(w).Encode(t)
+	if err != nil {
+		log.Debugf("marshal failed: %v", err)
+	}
+	return err
+}
+
+// Decode reads a toml tree from r
+func (b *TOMLBackend) Decode(r io.Reader) (*tree.Tree, error) {
+	var tree tree.Tree
+	_, err := toml.NewDecoder(r).Decode(&tree)
+	if err != nil {
+		return nil, err
+	}
+	return &tree, nil
+}
+
 // Save saves a tree to toml
 func (b *TOMLBackend) Save(path string, t *tree.Tree) error {
 	log.Debug("serialize tree...")
@@ -36,7 +57,10 @@ func (b *TOMLBackend) Save(path string, t *tree.Tree) error {
 	}
 
 	log.Debugf("write tree to \"%s\"...", path)
-	err = os.WriteFile(path, content, os.ModePerm)
+	err = writeAtomic(path, func(w io.Writer) error {
+		_, err := w.Write(content)
+		return err
+	})
 	if err != nil {
 		return err
 	}
@@ -11,6 +11,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/deadc0de6/gocatcli/internal/helpers"
 	"github.com/deadc0de6/gocatcli/internal/log"
 	"github.com/deadc0de6/gocatcli/internal/node"
 	"github.com/deadc0de6/gocatcli/internal/tree"
@@ -56,12 +57,13 @@ func convertFile(old *Node, storageID int) *node.FileNode {
 	newFile := &node.FileNode{
 		Name:      old.Name,
 		RelPath:   old.RelPath,
-		Checksum:  old.MD5,
+		Checksum:  helpers.FormatChecksum(helpers.DefaultChecksumAlgo, old.MD5),
 		Type:      node.FileTypeFile,
 		Size:      uint64(old.Size),
 		Maccess:   int64(old.MAccess),
 		StorageID: storageID,
 	}
+	newFile.ID = node.DeriveFileID(storageID, newFile.GetPath())
 	return newFile
 }
 
@@ -80,6 +82,7 @@ func convertArchive(old *Node, storageID int) *node.FileNode {
 func convertArchived(old *Node, storageID int) *node.FileNode {
 	newArchived := convertFile(old, storageID)
 	newArchived.Type = node.FileTypeArchived
+	newArchived.ID = node.DeriveFileID(storageID, newArchived.GetPath())
 	return newArchived
 }
 
@@ -0,0 +1,97 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package commands
+
+import (
+	"fmt"
+
+	"github.com/deadc0de6/gocatcli/internal/log"
+	"github.com/deadc0de6/gocatcli/internal/node"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	noteCmd = &cobra.Command{
+		Use:    "note <path> <note>",
+		Short:  "Add a note to files or directories (an empty note removes it)",
+		Args:   cobra.ExactArgs(2),
+		PreRun: preRun(true),
+		RunE:   note,
+	}
+
+	tagCmd = &cobra.Command{
+		Use:    "tag <path> <tag>",
+		Short:  "Add a tag to files or directories",
+		Args:   cobra.ExactArgs(2),
+		PreRun: preRun(true),
+		RunE:   tag,
+	}
+
+	untagCmd = &cobra.Command{
+		Use:    "untag <path> <tag>",
+		Short:  "Remove a tag from files or directories",
+		Args:   cobra.ExactArgs(2),
+		PreRun: preRun(true),
+		RunE:   untag,
+	}
+)
+
+func init() {
+	rootCmd.AddCommand(noteCmd)
+	rootCmd.AddCommand(tagCmd)
+	rootCmd.AddCommand(untagCmd)
+}
+
+// annotate applies fn to the files and directories found
+// at path and saves the catalog
+func annotate(path string, fn func(*node.FileNode)) error {
+	nodes := getStartPaths(path)
+	if len(nodes) < 1 {
+		return fmt.Errorf("no such path: \"%s\"", path)
+	}
+
+	cnt := 0
+	for _, n := range nodes {
+		fnode, ok := n.(*node.FileNode)
+		if !ok {
+			log.Warnf("\"%s\" is a %s, use the storage command", n.GetName(), n.GetType())
+			continue
+		}
+		fn(fnode)
+		// the nodes of the storage must be re-written
+		rootTree.GetStorageNode(fnode).SetDirty(true)
+		cnt++
+	}
+	if cnt < 1 {
+		return fmt.Errorf("no file or directory found at \"%s\"", path)
+	}
+
+	err := rootCatalog.Save(rootTree)
+	if err != nil {
+		return err
+	}
+	log.Infof("%d entry(ies) updated", cnt)
+	return nil
+}
+
+func note(_ *cobra.Command, args []string) error {
+	return annotate(args[0], func(n *node.FileNode) {
+		n.SetNote(args[1])
+	})
+}
+
+func tag(_ *cobra.Command, args []string) error {
+	return annotate(args[0], func(n *node.FileNode) {
+		n.Tag(args[1])
+	})
+}
+
+func untag(_ *cobra.Command, args []string) error {
+	return annotate(args[0], func(n *node.FileNode) {
+		n.Untag(args[1])
+	})
+}
@@ -0,0 +1,325 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deadc0de6/gocatcli/internal/catalog"
+	"github.com/deadc0de6/gocatcli/internal/helpers"
+	"github.com/deadc0de6/gocatcli/internal/log"
+	"github.com/deadc0de6/gocatcli/internal/search"
+	"github.com/deadc0de6/gocatcli/internal/tree"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	catalogCmd = &cobra.Command{
+		Use:   "catalog",
+		Short: "Manage the catalog file",
+	}
+
+	catalogBackupsCmd = &cobra.Command{
+		Use:    "backups",
+		Short:  "List the catalog backups",
+		Args:   cobra.NoArgs,
+		PreRun: preRunDebug,
+		RunE:   catalogBackups,
+	}
+
+	catalogRestoreCmd = &cobra.Command{
+		Use:    "restore [<index>]",
+		Short:  "Restore the catalog from one of its backups (defaults to the most recent)",
+		Args:   cobra.MaximumNArgs(1),
+		PreRun: preRunDebug,
+		RunE:   catalogRestore,
+	}
+
+	catalogUpgradeCmd = &cobra.Command{
+		Use:    "upgrade",
+		Short:  "Upgrade the catalog to the current schema version",
+		Args:   cobra.NoArgs,
+		PreRun: preRunDebug,
+		RunE:   catalogUpgrade,
+	}
+
+	catalogSearchIndexCmd = &cobra.Command{
+		Use:    "search-index",
+		Short:  "Build the search index used by find and fzfind",
+		Args:   cobra.NoArgs,
+		PreRun: preRunDebug,
+		RunE:   catalogSearchIndex,
+	}
+
+	catalogMergeCmd = &cobra.Command{
+		Use:    "merge <catalog> <catalog>...",
+		Short:  "Merge catalogs into a new catalog",
+		Args:   cobra.MinimumNArgs(2),
+		PreRun: preRunDebug,
+		RunE:   catalogMerge,
+	}
+
+	catalogExtractCmd = &cobra.Command{
+		Use:    "extract <storage>...",
+		Short:  "Extract storages into a new catalog",
+		Args:   cobra.MinimumNArgs(1),
+		PreRun: preRunSnapshot(true),
+		RunE:   catalogExtract,
+	}
+
+	catalogRestoreOptForce  bool
+	catalogSearchIndexOptRm bool
+	catalogOptOutput        string
+	catalogOptForce         bool
+	catalogMergeOptPolicy   string
+)
+
+func init() {
+	catalogCmd.AddCommand(catalogBackupsCmd)
+	catalogCmd.AddCommand(catalogRestoreCmd)
+	catalogCmd.AddCommand(catalogUpgradeCmd)
+	catalogCmd.AddCommand(catalogSearchIndexCmd)
+	catalogCmd.AddCommand(catalogMergeCmd)
+	catalogCmd.AddCommand(catalogExtractCmd)
+
+	rootCmd.AddCommand(catalogCmd)
+
+	// restore options
+	catalogRestoreCmd.PersistentFlags().BoolVarP(&catalogRestoreOptForce, "force", "f", false, "do not ask user")
+
+	// search index options
+	catalogSearchIndexCmd.PersistentFlags().BoolVarP(&catalogSearchIndexOptRm, "remove", "r", false, "remove the search index")
+
+	// merge and extract options
+	for _, cmd := range []*cobra.Command{catalogMergeCmd, catalogExtractCmd} {
+		cmd.PersistentFlags().StringVarP(&catalogOptOutput, "output", "o", "", "output catalog path")
+		cmd.PersistentFlags().BoolVarP(&catalogOptForce, "force", "f", false, "do not ask user")
+		_ = cmd.MarkPersistentFlagRequired("output")
+	}
+	hlp := fmt.Sprintf("policy for storages with the same name (%s)", strings.Join(tree.MergePolicies(), ","))
+	catalogMergeCmd.PersistentFlags().StringVarP(&catalogMergeOptPolicy, "policy", "p", string(tree.MergeRename), hlp)
+}
+
+func catalogBackups(_ *cobra.Command, _ []string) error {
+	c := newRootCatalog()
+	backups, err := c.GetBackups()
+	if err != nil {
+		return err
+	}
+	for _, backup := range backups {
+		date := helpers.DateToString(backup.ModTime.Unix())
+		fmt.Printf("%-3d %s %s\n", backup.Index, date, backup.Path)
+	}
+	return nil
+}
+
+func catalogRestore(_ *cobra.Command, args []string) error {
+	idx := 1
+	if len(args) > 0 {
+		var err error
+		idx, err = strconv.Atoi(args[0])
+		if err != nil {
+			return fmt.Errorf("bad backup index \"%s\"", args[0])
+		}
+	}
+
+	c := newRootCatalog()
+	question := fmt.Sprintf("Do you really want to restore \"%s\" from backup %d?", c.Path, idx)
+	if !catalogRestoreOptForce && !helpers.AskUser(question) {
+		log.Fatal(fmt.Errorf("user interrupted"))
+	}
+
+	err := c.Lock(true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := c.Unlock()
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+
+	err = c.Restore(idx)
+	if err != nil {
+		return err
+	}
+	log.Infof("\"%s\" restored from backup %d", c.Path, idx)
+	return nil
+}
+
+func catalogUpgrade(_ *cobra.Command, _ []string) error {
+	c := newRootCatalog()
+	if !helpers.FileExists(c.Path) {
+		return fmt.Errorf("catalog not found %s", c.Path)
+	}
+
+	err := c.Lock(true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := c.Unlock()
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+
+	applied, err := c.Upgrade()
+	if err != nil {
+		return err
+	}
+	if len(applied) < 1 {
+		log.Infof("\"%s\" is up to date (schema %d)", c.Path, tree.SchemaVersion)
+		return nil
+	}
+	for _, m := range applied {
+		fmt.Printf("schema %d -> %d: %s\n", m.From, m.From+1, m.Description)
+	}
+	log.Infof("\"%s\" upgraded to schema %d", c.Path, tree.SchemaVersion)
+	return nil
+}
+
+func catalogSearchIndex(_ *cobra.Command, _ []string) error {
+	c := newRootCatalog()
+	if catalogSearchIndexOptRm {
+		err := os.Remove(search.Path(c.Path))
+		if err != nil {
+			return err
+		}
+		log.Infof("search index of \"%s\" removed", c.Path)
+		return nil
+	}
+	if !helpers.FileExists(c.Path) {
+		return fmt.Errorf("catalog not found %s", c.Path)
+	}
+
+	err := c.Lock(false)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := c.Unlock()
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+
+	t, err := c.LoadTree()
+	if err != nil {
+		return err
+	}
+	idx := search.Build(t)
+	err = idx.Save(c.Path)
+	if err != nil {
+		return err
+	}
+	log.Infof("search index \"%s\" built (%d entries)", search.Path(c.Path), idx.Len())
+	return nil
+}
+
+// saveOutputCatalog saves the tree to the output catalog
+func saveOutputCatalog(t *tree.Tree) error {
+	c, err := catalog.NewCatalog(catalogOptOutput)
+	if err != nil {
+		return err
+	}
+	c.Backups = rootOptBackups
+
+	question := fmt.Sprintf("Do you really want to overwrite \"%s\"?", c.Path)
+	if helpers.FileExists(c.Path) && !catalogOptForce && !helpers.AskUser(question) {
+		log.Fatal(fmt.Errorf("user interrupted"))
+	}
+
+	err = c.Lock(true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err := c.Unlock()
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+
+	t.Updated = time.Now().Unix()
+	err = c.Save(t)
+	if err != nil {
+		return err
+	}
+	if search.Exists(c.Path) {
+		// do not leave a stale search index
+		err = search.Build(t).Save(c.Path)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func catalogMerge(_ *cobra.Command, args []string) error {
+	policy := tree.MergePolicy(catalogMergeOptPolicy)
+	if !slices.Contains(tree.MergePolicies(), catalogMergeOptPolicy) {
+		return fmt.Errorf("unsupported policy %s", catalogMergeOptPolicy)
+	}
+
+	merged, err := tree.NewTree(version)
+	if err != nil {
+		return err
+	}
+	for _, path := range args {
+		t, err := loadOtherCatalog(path)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		merged.Created = min(merged.Created, t.Created)
+		for _, storage := range t.Storages {
+			name := storage.GetName()
+			added := merged.AddStorage(storage, policy)
+			switch {
+			case len(added) < 1:
+				log.Warnf("storage \"%s\" from \"%s\" skipped", name, path)
+			case added != name:
+				log.Warnf("storage \"%s\" from \"%s\" renamed to \"%s\"", name, path, added)
+			}
+		}
+	}
+
+	err = saveOutputCatalog(merged)
+	if err != nil {
+		return err
+	}
+	log.Infof("%d catalog(s) merged to \"%s\" (%d storage(s))", len(args), catalogOptOutput, len(merged.Storages))
+	return nil
+}
+
+func catalogExtract(_ *cobra.Command, args []string) error {
+	extracted, err := tree.NewTree(version)
+	if err != nil {
+		return err
+	}
+	extracted.Created = rootTree.Created
+	for _, name := range args {
+		storage := rootTree.GetStorageByName(name)
+		if storage == nil {
+			return fmt.Errorf("no such storage: \"%s\"", name)
+		}
+		extracted.AddStorage(storage, tree.MergeSkip)
+	}
+
+	err = saveOutputCatalog(extracted)
+	if err != nil {
+		return err
+	}
+	log.Infof("%d storage(s) extracted to \"%s\"", len(extracted.Storages), catalogOptOutput)
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/deadc0de6/gocatcli/internal/catalog"
 	"github.com/deadc0de6/gocatcli/internal/catcli"
 	"github.com/deadc0de6/gocatcli/internal/helpers"
+	"github.com/deadc0de6/gocatcli/internal/log"
 
 	"github.com/spf13/cobra"
 )
@@ -47,10 +48,20 @@ func convert(_ *cobra.Command, args []string) error {
 	}
 
 	if len(convertOptOutput) > 0 {
-		c := catalog.NewCatalog(convertOptOutput)
-		if c == nil {
-			return fmt.Errorf("cannot construct catalog")
+		c, err := catalog.NewCatalog(convertOptOutput)
+		if err != nil {
+			return err
 		}
+		err = c.Lock(true)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			err := c.Unlock()
+			if err != nil {
+				log.Error(err)
+			}
+		}()
 		return c.Save(t)
 	}
 
@@ -20,7 +20,7 @@ var (
 	createCmd = &cobra.Command{
 		Use:    "create <local-path>",
 		Short:  "Create filesystem hierarchy locally",
-		PreRun: preRun(true),
+		PreRun: preRunLazy(true),
 		Args:   cobra.ExactArgs(1),
 		RunE:   create,
 	}
@@ -0,0 +1,78 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/deadc0de6/gocatcli/internal/colorme"
+	"github.com/deadc0de6/gocatcli/internal/differ"
+	"github.com/deadc0de6/gocatcli/internal/stringer"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffCmd = &cobra.Command{
+		Use:    "diff <catalogA> <catalogB>",
+		Short:  "Show the differences between two catalogs",
+		Long:   "List the entries added, removed, moved (same checksum) and changed from catalogA to catalogB (for example a backup and the current catalog)",
+		Args:   cobra.ExactArgs(2),
+		PreRun: preRunDebug,
+		RunE:   diff,
+	}
+
+	diffOptFormat   string
+	diffOptStorages []string
+	diffOptRawSize  bool
+)
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+
+	hlp := fmt.Sprintf("output format (%s)", strings.Join(stringer.GetDiffFormats(), ","))
+	diffCmd.PersistentFlags().StringVarP(&diffOptFormat, "format", "f", stringer.FormatNative, hlp)
+	diffCmd.PersistentFlags().StringSliceVarP(&diffOptStorages, "storage", "s", nil, "only compare this storage")
+	diffCmd.PersistentFlags().BoolVarP(&diffOptRawSize, "raw-size", "S", false, "do not humanize sizes when printing")
+}
+
+func diff(_ *cobra.Command, args []string) error {
+	if rootOptNoColor {
+		colorme.UseColors = false
+	}
+
+	m := &stringer.PrintMode{
+		RawSize:   diffOptRawSize,
+		Separator: separator,
+	}
+	prt, err := stringer.NewDiffStringer(diffOptFormat, m)
+	if err != nil {
+		return err
+	}
+
+	oldTree, err := loadOtherCatalog(args[0])
+	if err != nil {
+		return err
+	}
+	newTree, err := loadOtherCatalog(args[1])
+	if err != nil {
+		return err
+	}
+
+	for _, name := range diffOptStorages {
+		if oldTree.GetStorageByName(name) == nil && newTree.GetStorageByName(name) == nil {
+			return fmt.Errorf("no such storage \"%s\"", name)
+		}
+	}
+
+	diffs := differ.DiffTrees(oldTree, newTree, diffOptStorages)
+	prt.PrintPrefix()
+	for _, d := range diffs {
+		prt.Print(d)
+	}
+	return prt.PrintSuffix()
+}
@@ -9,6 +9,7 @@ import (
 	"cmp"
 	"fmt"
 	"slices"
+	"strings"
 
 	"github.com/deadc0de6/gocatcli/internal/node"
 	"github.com/deadc0de6/gocatcli/internal/stringer"
@@ -21,13 +22,16 @@ var (
 		Use:    "du [<path>]",
 		Short:  "Disk usage",
 		Args:   cobra.MaximumNArgs(1),
-		PreRun: preRun(true),
+		PreRun: preRunLazy(true),
 		RunE:   diskUsage,
 	}
 
 	duOptRawSize bool
 	duOptDepth   int
 	duOptSort    bool
+	duOptFormat  string
+
+	duFormats = []string{stringer.FormatNative, stringer.FormatJSON, stringer.FormatNDJSON}
 )
 
 func init() {
@@ -36,9 +40,14 @@ func init() {
 	duCmd.PersistentFlags().BoolVarP(&duOptRawSize, "raw-size", "S", false, "do not humanize sizes when printing")
 	duCmd.PersistentFlags().IntVarP(&duOptDepth, "depth", "D", -1, "max depth")
 	duCmd.PersistentFlags().BoolVarP(&duOptSort, "sort", "s", false, "sort by size")
+	hlp := fmt.Sprintf("output format (%s)", strings.Join(duFormats, ","))
+	duCmd.PersistentFlags().StringVarP(&duOptFormat, "format", "f", stringer.FormatNative, hlp)
 }
 
 func diskUsage(_ *cobra.Command, args []string) error {
+	if !slices.Contains(duFormats, duOptFormat) {
+		return fmt.Errorf("unsupported format %s", duOptFormat)
+	}
 	var path string
 	if len(args) > 0 {
 		path = args[0]
@@ -64,7 +73,25 @@ func diskUsage(_ *cobra.Command, args []string) error {
 		RawSize:     duOptRawSize,
 		Separator:   separator,
 	}
-	stringer := stringer.NewDuStringer(rootTree, m)
+	// print each dir with its total size
+	var printNode func(node.Node)
+	if duOptFormat == stringer.FormatNative {
+		prt := stringer.NewDuStringer(rootTree, m)
+		printNode = func(n node.Node) {
+			prt.Print(n, 0, true)
+		}
+	} else {
+		prt, err := stringer.GetStringer(rootTree, duOptFormat, m)
+		if err != nil {
+			return err
+		}
+		prt.PrintPrefix()
+		defer prt.PrintSuffix()
+		printNode = func(n node.Node) {
+			prt.Print(n, 0)
+		}
+	}
+
 	for _, n := range startNodes {
 		var nodes []node.Node
 		callback := func(n node.Node, _ int, _ node.Node) bool {
@@ -75,7 +102,7 @@ func diskUsage(_ *cobra.Command, args []string) error {
 			if duOptSort {
 				nodes = append(nodes, n)
 			} else {
-				stringer.Print(n, 0, true)
+				printNode(n)
 			}
 			return true
 		}
@@ -86,10 +113,10 @@ func diskUsage(_ *cobra.Command, args []string) error {
 				return cmp.Compare(left.GetSize(), right.GetSize())
 			})
 			for _, n := range nodes {
-				stringer.Print(n, 0, true)
+				printNode(n)
 			}
 		}
-		stringer.Print(n, 0, true)
+		printNode(n)
 	}
 
 	return nil
@@ -0,0 +1,266 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package commands
+
+import (
+	"cmp"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/deadc0de6/gocatcli/internal/colorme"
+	"github.com/deadc0de6/gocatcli/internal/helpers"
+	"github.com/deadc0de6/gocatcli/internal/log"
+	"github.com/deadc0de6/gocatcli/internal/node"
+	"github.com/deadc0de6/gocatcli/internal/stringer"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	dupesCmd = &cobra.Command{
+		Use:    "dupes [<storage>...]",
+		Short:  "Find duplicate files across storages",
+		Long:   "Find duplicate files (same size and checksum) in all storages or the selected ones (wildcards supported)",
+		PreRun: preRunLazy(true),
+		RunE:   dupes,
+	}
+
+	dupesOptFormat  string
+	dupesOptRawSize bool
+	dupesOptCross   bool
+	dupesOptUnique  bool
+)
+
+// dupesGroup files with the same content
+type dupesGroup struct {
+	checksum string
+	size     uint64
+	files    []*node.FileNode
+	storages map[*node.StorageNode]bool
+	// same size files were hashed with another algorithm
+	mixed bool
+}
+
+// wasted space by the copies
+func (g *dupesGroup) wasted() uint64 {
+	return g.size * uint64(len(g.files)-1)
+}
+
+func init() {
+	rootCmd.AddCommand(dupesCmd)
+
+	hlp := fmt.Sprintf("output format (%s)", strings.Join(stringer.GetSupportedFormats(false, true), ","))
+	dupesCmd.PersistentFlags().StringVarP(&dupesOptFormat, "format", "f", "native", hlp)
+	dupesCmd.PersistentFlags().BoolVarP(&dupesOptRawSize, "raw-size", "S", false, "do not humanize sizes when printing")
+	dupesCmd.PersistentFlags().BoolVarP(&dupesOptCross, "cross-storage", "x", false, "only duplicates found on different storages")
+	dupesCmd.PersistentFlags().BoolVarP(&dupesOptUnique, "unique", "u", false, "list the files found on a single storage instead")
+}
+
+// getStoragesByPatterns returns the storages matching any of the patterns
+func getStoragesByPatterns(patterns []string) ([]*node.StorageNode, error) {
+	if len(patterns) < 1 {
+		return rootTree.GetStorages(), nil
+	}
+	var storages []*node.StorageNode
+	for _, storage := range rootTree.GetStorages() {
+		for _, patt := range patterns {
+			matched, err := filepath.Match(patt, storage.GetName())
+			if err != nil {
+				return nil, err
+			}
+			if matched {
+				storages = append(storages, storage)
+				break
+			}
+		}
+	}
+	if len(storages) < 1 {
+		return nil, fmt.Errorf("no storage matching \"%s\"", strings.Join(patterns, ","))
+	}
+	return storages, nil
+}
+
+// groupByContent groups the files of the storages by size and then checksum,
+// also returns the number of files without checksum and the ones that cannot
+// be compared because of different checksum algorithms
+func groupByContent(storages []*node.StorageNode) ([]*dupesGroup, int, int) {
+	var noChecksum int
+	var mixed int
+	bySize := make(map[uint64][]*node.FileNode)
+	callback := func(n node.Node, _ int, _ node.Node) bool {
+		typ := n.GetType()
+		if typ != node.FileTypeFile && typ != node.FileTypeArchive {
+			return true
+		}
+		f := n.(*node.FileNode)
+		if f.Size == 0 {
+			// empty files are not duplicates
+			return false
+		}
+		if len(f.Checksum) < 1 {
+			noChecksum++
+			return false
+		}
+		bySize[f.Size] = append(bySize[f.Size], f)
+		// archives content is not checksummed
+		return false
+	}
+	for _, storage := range storages {
+		rootTree.ProcessChildren(storage, true, callback, -1)
+	}
+
+	var groups []*dupesGroup
+	for size, files := range bySize {
+		if len(files) < 2 && !dupesOptUnique {
+			// cannot have duplicate
+			continue
+		}
+		algos := make(map[string]bool)
+		for _, f := range files {
+			algo, _ := helpers.SplitChecksum(f.Checksum)
+			algos[algo] = true
+		}
+		isMixed := len(algos) > 1
+		if isMixed {
+			mixed += len(files)
+		}
+		byChecksum := make(map[string]*dupesGroup)
+		for _, f := range files {
+			g, ok := byChecksum[f.Checksum]
+			if !ok {
+				g = &dupesGroup{
+					checksum: f.Checksum,
+					size:     size,
+					storages: make(map[*node.StorageNode]bool),
+					mixed:    isMixed,
+				}
+				byChecksum[f.Checksum] = g
+				groups = append(groups, g)
+			}
+			g.files = append(g.files, f)
+			g.storages[rootTree.GetStorageNode(f)] = true
+		}
+	}
+	return groups, noChecksum, mixed
+}
+
+func dupes(_ *cobra.Command, args []string) error {
+	if !formatOk(dupesOptFormat, false, true) {
+		return fmt.Errorf("unsupported format %s", dupesOptFormat)
+	}
+
+	storages, err := getStoragesByPatterns(args)
+	if err != nil {
+		return err
+	}
+
+	groups, noChecksum, mixed := groupByContent(storages)
+	if noChecksum > 0 {
+		log.Warnf("%d file(s) without checksum ignored, index with --checksum", noChecksum)
+	}
+	if mixed > 0 {
+		log.Warnf("%d file(s) hashed with different checksum algorithms cannot be compared", mixed)
+	}
+
+	m := &stringer.PrintMode{
+		FullPath:    true,
+		Long:        true,
+		InlineColor: false,
+		RawSize:     dupesOptRawSize,
+		Separator:   separator,
+	}
+	stringGetter, err := stringer.GetStringer(rootTree, dupesOptFormat, m)
+	if err != nil {
+		return err
+	}
+
+	if dupesOptUnique {
+		printUniques(groups, stringGetter)
+		return nil
+	}
+
+	var selected []*dupesGroup
+	for _, g := range groups {
+		if len(g.files) < 2 {
+			continue
+		}
+		if dupesOptCross && len(g.storages) < 2 {
+			continue
+		}
+		selected = append(selected, g)
+	}
+
+	// most wasted space first
+	slices.SortFunc(selected, func(left, right *dupesGroup) int {
+		if c := cmp.Compare(right.wasted(), left.wasted()); c != 0 {
+			return c
+		}
+		return cmp.Compare(left.checksum, right.checksum)
+	})
+
+	var total uint64
+	cm := colorme.NewColorme(false)
+	stringGetter.PrintPrefix()
+	for _, g := range selected {
+		sortFiles(g.files)
+		if dupesOptFormat == stringer.FormatNative {
+			fmt.Printf("%s %s\n", cm.InGray(fmt.Sprintf("%d copies of", len(g.files))),
+				cm.InGreen(fmt.Sprintf("%s (%s wasted) %s", dupesSize(g.size), dupesSize(g.wasted()), g.checksum)))
+		}
+		for _, f := range g.files {
+			stringGetter.Print(f, 1)
+		}
+		total += g.wasted()
+	}
+	stringGetter.PrintSuffix()
+
+	log.Debugf("%d group(s) of duplicates, %s wasted", len(selected), dupesSize(total))
+	return nil
+}
+
+// printUniques prints the files whose content is found on a single storage
+func printUniques(groups []*dupesGroup, prt stringer.Stringer) {
+	var uniques []*node.FileNode
+	for _, g := range groups {
+		if g.mixed {
+			// a copy may exist under another algorithm
+			continue
+		}
+		if len(g.storages) == 1 {
+			uniques = append(uniques, g.files...)
+		}
+	}
+	sortFiles(uniques)
+
+	prt.PrintPrefix()
+	for _, f := range uniques {
+		prt.Print(f, 0)
+	}
+	prt.PrintSuffix()
+}
+
+// sortFiles sorts by storage and path
+func sortFiles(files []*node.FileNode) {
+	slices.SortFunc(files, func(left, right *node.FileNode) int {
+		leftSto := rootTree.GetStorageNode(left)
+		rightSto := rootTree.GetStorageNode(right)
+		if leftSto != nil && rightSto != nil {
+			if c := cmp.Compare(leftSto.GetName(), rightSto.GetName()); c != 0 {
+				return c
+			}
+		}
+		return cmp.Compare(left.GetPath(), right.GetPath())
+	})
+}
+
+func dupesSize(size uint64) string {
+	if dupesOptRawSize {
+		return fmt.Sprintf("%d", size)
+	}
+	return helpers.SizeToHuman(size)
+}
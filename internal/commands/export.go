@@ -0,0 +1,76 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/deadc0de6/gocatcli/internal/exporter"
+	"github.com/deadc0de6/gocatcli/internal/log"
+	"github.com/deadc0de6/gocatcli/internal/node"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	exportCmd = &cobra.Command{
+		Use:   "export",
+		Short: "Export the catalog",
+	}
+
+	exportHTMLCmd = &cobra.Command{
+		Use:    "html <out-dir> [<path>]",
+		Short:  "Export the catalog (or a subtree) as a static website",
+		Long:   "Generate a static, browsable and searchable offline website of the catalog with one page per directory",
+		Args:   cobra.RangeArgs(1, 2),
+		PreRun: preRunLazy(true),
+		RunE:   exportHTML,
+	}
+
+	exportOptShowAll bool
+	exportOptForce   bool
+)
+
+func init() {
+	exportCmd.AddCommand(exportHTMLCmd)
+
+	rootCmd.AddCommand(exportCmd)
+
+	exportHTMLCmd.PersistentFlags().BoolVarP(&exportOptShowAll, "all", "a", false, "do not ignore entries starting with a dot")
+	exportHTMLCmd.PersistentFlags().BoolVarP(&exportOptForce, "force", "f", false, "write to a non-empty directory")
+}
+
+func exportHTML(_ *cobra.Command, args []string) error {
+	outDir := args[0]
+	entries, err := os.ReadDir(outDir)
+	if err == nil && len(entries) > 0 && !exportOptForce {
+		return fmt.Errorf("\"%s\" is not empty (use --force)", outDir)
+	}
+
+	var startNodes []node.Node
+	if len(args) > 1 {
+		startNodes = getStartPaths(args[1])
+		if startNodes == nil {
+			return fmt.Errorf("no such start path: \"%s\"", args[1])
+		}
+	} else {
+		startNodes = rootTree.GetTops("")
+	}
+
+	exp, err := exporter.NewHTMLExporter(rootTree, filepath.Base(rootOptCatalogPath), exportOptShowAll)
+	if err != nil {
+		return err
+	}
+	err = exp.Export(startNodes, outDir)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("exported %d page(s) to \"%s\"", exp.PageCount(), filepath.Join(outDir, "index.html"))
+	return nil
+}
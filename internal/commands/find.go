@@ -7,6 +7,7 @@ package commands
 
 import (
 	"fmt"
+	"path/filepath"
 	"regexp"
 	"strings"
 	"time"
@@ -14,6 +15,8 @@ import (
 	"github.com/deadc0de6/gocatcli/internal/helpers"
 	"github.com/deadc0de6/gocatcli/internal/log"
 	"github.com/deadc0de6/gocatcli/internal/node"
+	"github.com/deadc0de6/gocatcli/internal/query"
+	"github.com/deadc0de6/gocatcli/internal/search"
 	"github.com/deadc0de6/gocatcli/internal/stringer"
 	"github.com/deadc0de6/gocatcli/internal/tree"
 
@@ -22,15 +25,18 @@ import (
 
 var (
 	findCmd = &cobra.Command{
-		Use:    "find [<pattern>]",
+		Use:    "find [<pattern>|<query>]",
 		Short:  "Find files in the catalog",
-		PreRun: preRun(true),
+		Long:   "Find files matching the patterns, an argument comparing fields (like \"size>1G and mime~video/\") is used as a query (see --query)",
+		PreRun: preRunLazy(true),
 		RunE:   find,
 	}
 
 	findOptStart  string
 	findOptFormat string
 	findOptDepth  int
+	findOptQuery  string
+	findOptFull   bool
 )
 
 func init() {
@@ -40,6 +46,8 @@ func init() {
 	hlp := fmt.Sprintf("output format (%s)", strings.Join(stringer.GetSupportedFormats(false, true), ","))
 	findCmd.PersistentFlags().StringVarP(&findOptFormat, "format", "f", "native", hlp)
 	findCmd.PersistentFlags().IntVarP(&findOptDepth, "depth", "D", -1, "max depth")
+	findCmd.PersistentFlags().BoolVarP(&findOptFull, "full-path", "P", false, "match the pattern against the full path (storage/path, \"**\" matches any directories)")
+	findCmd.PersistentFlags().StringVarP(&findOptQuery, "query", "q", "", fmt.Sprintf("only entries matching the query (fields: %s)", strings.Join(query.Fields(), ",")))
 }
 
 func find(_ *cobra.Command, args []string) error {
@@ -50,7 +58,32 @@ func find(_ *cobra.Command, args []string) error {
 		return fmt.Errorf("unsupported format %s", findOptFormat)
 	}
 
-	if len(args) < 1 {
+	// queries may be given as arguments
+	var queries []string
+	if len(findOptQuery) > 0 {
+		queries = append(queries, findOptQuery)
+	}
+	var patterns []string
+	for _, arg := range args {
+		if query.IsQuery(arg) {
+			queries = append(queries, arg)
+			continue
+		}
+		patterns = append(patterns, arg)
+	}
+	args = patterns
+
+	var q *query.Query
+	if len(queries) > 0 {
+		var err error
+		q, err = query.Parse("(" + strings.Join(queries, ") and (") + ")")
+		if err != nil {
+			return err
+		}
+		log.Debugf("query: %s", q)
+	}
+
+	if len(args) < 1 && q == nil {
 		// calling ls when no args are provided
 		log.Debugf("running ls recursive...")
 		return ls("", findOptFormat, true, false, true, findOptDepth, true)
@@ -82,49 +115,170 @@ func find(_ *cobra.Command, args []string) error {
 		}
 	}
 
+	stringGetter.PrintPrefix()
+	defer stringGetter.PrintSuffix()
+
+	if len(args) < 1 {
+		// only the query
+		for _, startNode := range startNodes {
+			matchNodes(rootTree, startNode, nil, q, stringGetter)
+		}
+		return nil
+	}
+
+	var idx *search.Index
+	if !findOptFull {
+		idx = loadSearchIndex()
+	}
+
 	for _, arg := range args {
-		// patch pattern
-		arg = helpers.PatchPattern(arg)
-		// get the pattern to search for
-		patt := arg
-		re, err := regexp.Compile(patt)
+		match, err := getMatcher(rootTree, arg, findOptFull)
 		if err != nil {
 			return err
 		}
-		log.Debugf("search pattern: %s", patt)
-
+		var candidates []int
+		var indexed bool
+		if idx != nil {
+			candidates, indexed = getCandidates(idx, arg)
+		}
 		for _, startNode := range startNodes {
-			matchNodes(rootTree, startNode, re, stringGetter)
+			if indexed {
+				matchCandidates(rootTree, idx, candidates, startNode, match, q, stringGetter)
+				continue
+			}
+			matchNodes(rootTree, startNode, match, q, stringGetter)
 		}
 	}
 
 	return nil
 }
 
-// find in the tree every node from "startNode" where its name
-// matches the pattern "patt"
-func matchNodes(t *tree.Tree, startNode node.Node, patt *regexp.Regexp, prt stringer.Stringer) {
+// loadSearchIndex returns the search index of the
+// catalog or nil if there is none or it is stale
+func loadSearchIndex() *search.Index {
+	if isFederated() || !search.Exists(rootOptCatalogPath) {
+		return nil
+	}
+	t0 := time.Now()
+	idx, err := search.Load(rootOptCatalogPath)
+	if err != nil {
+		log.Debugf("not using search index: %v", err)
+		return nil
+	}
+	log.Debugf("search index loaded with %d entries in %v", idx.Len(), time.Since(t0))
+	return idx
+}
+
+// getCandidates returns the entries of the index that may match
+// the pattern, false if the index cannot be used for this pattern
+func getCandidates(idx *search.Index, arg string) ([]int, bool) {
+	literals, ok := search.Literals(arg)
+	if !ok {
+		log.Debugf("pattern \"%s\" cannot use the search index", arg)
+		return nil, false
+	}
+	candidates, ok := idx.Candidates(literals)
+	if !ok {
+		log.Debugf("pattern \"%s\" too short for the search index", arg)
+		return nil, false
+	}
+	log.Debugf("search index returned %d candidate(s) for \"%s\"", len(candidates), arg)
+	return candidates, true
+}
+
+// like matchNodes but only for the candidates of the search
+// index that are below "startNode"
+func matchCandidates(t *tree.Tree, idx *search.Index, candidates []int, startNode node.Node, match func(node.Node) bool, q *query.Query, prt stringer.Stringer) {
+	if node.IsCollection(startNode) {
+		for _, sto := range t.GetCollectionStorages(startNode.GetPath()) {
+			matchCandidates(t, idx, candidates, sto, match, q, prt)
+		}
+		return
+	}
+	var cnt int64
+
+	t0 := time.Now()
+	sto := t.GetStorageNode(startNode)
+	prefix := ""
+	if !node.IsStorage(startNode) {
+		prefix = startNode.GetPath() + string(filepath.Separator)
+	}
+
+	for _, pos := range candidates {
+		if idx.StorageName(pos) != sto.GetName() || !strings.HasPrefix(idx.RelPath(pos), prefix) {
+			continue
+		}
+		n := idx.Resolve(t, pos)
+		if n == nil {
+			log.Debugf("search index entry \"%s\" not found", idx.RelPath(pos))
+			continue
+		}
+		if !match(n) {
+			continue
+		}
+		if q != nil && !q.Match(n, sto) {
+			continue
+		}
+		prt.Print(n, 0)
+		cnt++
+	}
+
+	log.Debugf("found %d matching entries with the search index in %v", cnt, time.Since(t0))
+}
+
+// getMatcher returns a function matching nodes against the pattern,
+// either their name or their full path (storage/relpath)
+func getMatcher(t *tree.Tree, arg string, fullPath bool) (func(node.Node) bool, error) {
+	if fullPath {
+		log.Debugf("search full path pattern: %s", arg)
+		isGlob := strings.ContainsAny(arg, "*?[")
+		return func(n node.Node) bool {
+			path := n.GetName()
+			if sto := t.GetStorageNode(n); sto != nil && !node.IsStorage(n) {
+				path = filepath.Join(sto.GetName(), n.GetPath())
+			}
+			if isGlob {
+				return helpers.MatchGlob(arg, path)
+			}
+			return strings.Contains(path, arg)
+		}, nil
+	}
+
+	// patch pattern
+	patt := helpers.PatchPattern(arg)
+	// get the pattern to search for
+	re, err := regexp.Compile(patt)
+	if err != nil {
+		return nil, err
+	}
+	log.Debugf("search pattern: %s", patt)
+	return func(n node.Node) bool {
+		return re.MatchString(n.GetName())
+	}, nil
+}
+
+// find in the tree every node from "startNode" matched by
+// "match" (if any) and matching the query "q" (if any)
+func matchNodes(t *tree.Tree, startNode node.Node, match func(node.Node) bool, q *query.Query, prt stringer.Stringer) {
 	var cnt int64
 
 	t0 := time.Now()
 	callback := func(n node.Node, _ int, _ node.Node) bool {
-		name := n.GetName()
-		log.Debugf("matching name \"%s\" against pattern %v", name, patt)
-		ret := patt.MatchString(name)
-		if ret {
-			log.Debugf("\"%s\" matching \"%v\": %v", name, patt, ret)
-			prt.Print(n, 0)
-			cnt++
+		if match != nil && !match(n) {
+			return true
+		}
+		if q != nil && !q.Match(n, t.GetStorageNode(n)) {
+			return true
 		}
+		log.Debugf("\"%s\" matching", n.GetName())
+		prt.Print(n, 0)
+		cnt++
 		// always continue
 		return true
 	}
 
-	prt.PrintPrefix()
 	// process all elements of tree
-	log.Debugf("processing children and looking for name pattern: %v", patt)
 	t.ProcessChildren(startNode, true, callback, -1)
-	prt.PrintSuffix()
 
-	log.Debugf("found %d entries matching \"%s\" in %v", cnt, patt.String(), time.Since(t0))
+	log.Debugf("found %d matching entries in %v", cnt, time.Since(t0))
 }
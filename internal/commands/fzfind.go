@@ -12,6 +12,7 @@ import (
 
 	"github.com/deadc0de6/gocatcli/internal/log"
 	"github.com/deadc0de6/gocatcli/internal/node"
+	"github.com/deadc0de6/gocatcli/internal/search"
 	"github.com/deadc0de6/gocatcli/internal/stringer"
 
 	"github.com/ktr0731/go-fuzzyfinder"
@@ -22,7 +23,7 @@ var (
 	fzfindCmd = &cobra.Command{
 		Use:    "fzfind [<path>]",
 		Short:  "Fuzzy find files in the catalog",
-		PreRun: preRun(true),
+		PreRun: preRunLazy(true),
 		RunE:   fzFind,
 	}
 
@@ -35,6 +36,16 @@ type fzfEntry struct {
 	Path    string
 	item    node.Node
 	storage *node.StorageNode
+	idx     *search.Index // resolves item when nil
+	pos     int
+}
+
+// getItem returns the node of the entry
+func (e *fzfEntry) getItem() node.Node {
+	if e.item == nil && e.idx != nil {
+		e.item = e.idx.Resolve(rootTree, e.pos)
+	}
+	return e.item
 }
 
 func init() {
@@ -85,7 +96,12 @@ func fzFind(_ *cobra.Command, args []string) error {
 	// list of entries
 	var entries []*fzfEntry
 	log.Debugf("start nodes: %v", startNodes)
+	idx := loadSearchIndex()
 	for _, foundNode := range startNodes {
+		if idx != nil {
+			entries = append(entries, fzFindFillListFromIndex(idx, foundNode)...)
+			continue
+		}
 		entries = append(entries, fzFindFillList(foundNode)...)
 	}
 
@@ -100,18 +116,22 @@ func fzFind(_ *cobra.Command, args []string) error {
 			return ""
 		}
 		entry := entries[i]
+		item := entry.getItem()
+		if item == nil {
+			return ""
+		}
 		var outs []string
 		outs = append(outs, fmt.Sprintf("storage: %s", entry.storage.Name))
-		outs = append(outs, fmt.Sprintf("path: %s", entry.item.GetPath()))
+		outs = append(outs, fmt.Sprintf("path: %s", item.GetPath()))
 
-		entryAttrs := entry.item.GetAttr(m.RawSize, m.Long)
+		entryAttrs := item.GetAttr(m.RawSize, m.Long)
 		attrs := stringer.AttrsToString(entryAttrs, m, "\n")
 
 		return strings.Join(outs, "\n") + "\n" + attrs
 	}
 
 	// display fzf finder interface
-	idx, err := fuzzyfinder.Find(
+	selected, err := fuzzyfinder.Find(
 		entries,
 		getItemFunc,
 		fuzzyfinder.WithPreviewWindow(previewFunc),
@@ -121,10 +141,13 @@ func fzFind(_ *cobra.Command, args []string) error {
 	}
 
 	// print result
-	if idx > -1 && idx < len(entries) {
+	if selected > -1 && selected < len(entries) {
 		// list parent directory
-		entry := entries[idx]
+		entry := entries[selected]
 		log.Debugf("selected entry: %s", entry.Path)
+		if entry.getItem() == nil {
+			return fmt.Errorf("entry \"%s\" not found", entry.Path)
+		}
 
 		// get the parent
 		hasChildren := false
@@ -152,6 +175,12 @@ func fzFind(_ *cobra.Command, args []string) error {
 
 func fzFindFillList(n node.Node) []*fzfEntry {
 	var list []*fzfEntry
+	if node.IsCollection(n) {
+		for _, sto := range rootTree.GetCollectionStorages(n.GetPath()) {
+			list = append(list, fzFindFillList(sto)...)
+		}
+		return list
+	}
 	top := rootTree.GetStorageNode(n)
 	callback := func(n node.Node, _ int, _ node.Node) bool {
 		item := &fzfEntry{
@@ -165,3 +194,47 @@ func fzFindFillList(n node.Node) []*fzfEntry {
 	rootTree.ProcessChildren(n, fzFindOptShowAll, callback, -1)
 	return list
 }
+
+// fzFindFillListFromIndex lists the entries below n
+// from the search index without walking the tree
+func fzFindFillListFromIndex(idx *search.Index, n node.Node) []*fzfEntry {
+	var list []*fzfEntry
+	if node.IsCollection(n) {
+		for _, sto := range rootTree.GetCollectionStorages(n.GetPath()) {
+			list = append(list, fzFindFillListFromIndex(idx, sto)...)
+		}
+		return list
+	}
+	top := rootTree.GetStorageNode(n)
+	prefix := ""
+	if !node.IsStorage(n) {
+		prefix = n.GetPath() + string(filepath.Separator)
+	}
+	for _, pos := range idx.StorageEntries(top.GetName()) {
+		path := idx.RelPath(pos)
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		if !fzFindOptShowAll && isHiddenPath(strings.TrimPrefix(path, prefix)) {
+			continue
+		}
+		item := &fzfEntry{
+			Path:    filepath.Join(top.GetName(), path),
+			storage: top,
+			idx:     idx,
+			pos:     pos,
+		}
+		list = append(list, item)
+	}
+	return list
+}
+
+// isHiddenPath returns true if any component of path is hidden
+func isHiddenPath(path string) bool {
+	for _, part := range strings.Split(path, string(filepath.Separator)) {
+		if strings.HasPrefix(part, ".") {
+			return true
+		}
+	}
+	return false
+}
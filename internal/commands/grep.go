@@ -0,0 +1,188 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package commands
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/deadc0de6/gocatcli/internal/colorme"
+	"github.com/deadc0de6/gocatcli/internal/log"
+	"github.com/deadc0de6/gocatcli/internal/node"
+	"github.com/deadc0de6/gocatcli/internal/search"
+
+	"github.com/spf13/cobra"
+)
+
+const (
+	// characters shown around a match
+	grepContext = 40
+)
+
+var (
+	grepCmd = &cobra.Command{
+		Use:    "grep <pattern> [<path>]",
+		Short:  "Search the content of the files (see index --content)",
+		Args:   cobra.RangeArgs(1, 2),
+		PreRun: preRunLazy(true),
+		RunE:   grep,
+	}
+
+	grepOptIgnoreCase bool
+	grepOptRegex      bool
+	grepOptFilesOnly  bool
+	grepOptMaxCount   int
+)
+
+func init() {
+	rootCmd.AddCommand(grepCmd)
+
+	grepCmd.PersistentFlags().BoolVarP(&grepOptIgnoreCase, "ignore-case", "i", false, "ignore case")
+	grepCmd.PersistentFlags().BoolVarP(&grepOptRegex, "regex", "E", false, "the pattern is a regex")
+	grepCmd.PersistentFlags().BoolVarP(&grepOptFilesOnly, "files-with-matches", "l", false, "only print the paths of the matching files")
+	grepCmd.PersistentFlags().IntVarP(&grepOptMaxCount, "max-count", "m", 3, "max number of matching lines printed per file (0 for all)")
+}
+
+// grepMatches returns the text of the documents matching re by node id,
+// for each catalog since federated catalogs may share the same ids
+func grepMatches(re *regexp.Regexp, pattern string) ([]map[string]string, error) {
+	matches := make([]map[string]string, len(rootOptCatalogPaths))
+	found := false
+	for i, path := range rootOptCatalogPaths {
+		matches[i] = make(map[string]string)
+		if !search.ContentExists(path) {
+			log.Debugf("no full-text index for \"%s\"", path)
+			continue
+		}
+		content, err := search.LoadContent(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		found = true
+
+		// the words of a regex are not known
+		words := pattern
+		if grepOptRegex {
+			words = ""
+		}
+		candidates := content.Candidates(words)
+		log.Debugf("full-text index of \"%s\" returned %d candidate(s)", path, len(candidates))
+		for _, doc := range candidates {
+			if re.MatchString(doc.Text) {
+				matches[i][doc.ID] = doc.Text
+			}
+		}
+	}
+	if !found {
+		return nil, fmt.Errorf("no full-text index found, index with --content")
+	}
+	return matches, nil
+}
+
+// grepSnippets returns the matching lines of text
+// as "<line number>:<snippet>"
+func grepSnippets(text string, re *regexp.Regexp, cm *colorme.ColorMe) []string {
+	var snippets []string
+	for i, line := range strings.Split(text, "\n") {
+		loc := re.FindStringIndex(line)
+		if loc == nil {
+			continue
+		}
+		start := max(0, loc[0]-grepContext)
+		for start > 0 && !utf8.RuneStart(line[start]) {
+			start--
+		}
+		end := min(len(line), loc[1]+grepContext)
+		for end < len(line) && !utf8.RuneStart(line[end]) {
+			end++
+		}
+		snippet := line[start:loc[0]] + cm.InRed(line[loc[0]:loc[1]]) + line[loc[1]:end]
+		snippet = strings.TrimSpace(snippet)
+		if start > 0 {
+			snippet = "..." + snippet
+		}
+		if end < len(line) {
+			snippet += "..."
+		}
+		snippets = append(snippets, fmt.Sprintf("%d:%s", i+1, snippet))
+		if grepOptMaxCount > 0 && len(snippets) >= grepOptMaxCount {
+			break
+		}
+	}
+	return snippets
+}
+
+func grep(_ *cobra.Command, args []string) error {
+	pattern := args[0]
+	expr := pattern
+	if !grepOptRegex {
+		expr = regexp.QuoteMeta(pattern)
+	}
+	if grepOptIgnoreCase {
+		expr = "(?i)" + expr
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return err
+	}
+
+	matches, err := grepMatches(re, pattern)
+	if err != nil {
+		return err
+	}
+
+	var startNodes []node.Node
+	if len(args) > 1 {
+		startNodes = getStartPaths(args[1])
+		if startNodes == nil {
+			return fmt.Errorf("no such path: \"%s\"", args[1])
+		}
+	} else {
+		for _, top := range rootTree.GetStorages() {
+			startNodes = append(startNodes, top)
+		}
+	}
+
+	// the documents of the files still in the catalog are printed
+	cm := colorme.NewColorme(false)
+	cnt := 0
+	printMatch := func(n node.Node, _ int, _ node.Node) bool {
+		f, ok := n.(*node.FileNode)
+		if !ok {
+			return true
+		}
+		sto := rootTree.GetStorageNode(f)
+		if sto == nil {
+			return true
+		}
+		docs := matches[rootTree.GetOrigin(sto)]
+		text, ok := docs[f.ID]
+		if !ok {
+			return true
+		}
+		delete(docs, f.ID)
+		cnt++
+		path := filepath.Join(sto.GetName(), f.GetPath())
+		if grepOptFilesOnly {
+			fmt.Println(cm.InGreen(path))
+			return true
+		}
+		for _, snippet := range grepSnippets(text, re, cm) {
+			fmt.Printf("%s:%s\n", cm.InGreen(path), snippet)
+		}
+		return true
+	}
+	for _, startNode := range startNodes {
+		// the start path may be a file
+		printMatch(startNode, 0, nil)
+		rootTree.ProcessChildren(startNode, true, printMatch, -1)
+	}
+	log.Debugf("%d file(s) matching \"%s\"", cnt, pattern)
+	return nil
+}
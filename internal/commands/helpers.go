@@ -20,7 +20,8 @@ func getStartPaths(path string) []node.Node {
 	if !strings.Contains(path, "*") && len(storages) == 1 {
 		// complete if single storage
 		name := storages[0].GetName()
-		if !strings.HasPrefix(path, name) {
+		collection := strings.Split(storages[0].Collection, node.CollectionSeparator)[0]
+		if !strings.HasPrefix(path, name) && (len(collection) < 1 || !strings.HasPrefix(path, collection)) {
 			path = filepath.Join(name, path)
 		}
 	}
@@ -9,11 +9,14 @@ import (
 	"fmt"
 	"path/filepath"
 	"regexp"
+	"runtime"
+	"strings"
 	"time"
 
 	"github.com/deadc0de6/gocatcli/internal/helpers"
 	"github.com/deadc0de6/gocatcli/internal/log"
 	"github.com/deadc0de6/gocatcli/internal/node"
+	"github.com/deadc0de6/gocatcli/internal/search"
 	"github.com/deadc0de6/gocatcli/internal/tree"
 	"github.com/deadc0de6/gocatcli/internal/walker"
 
@@ -32,12 +35,20 @@ var (
 
 	indexOptTags     []string
 	indexOptChecksum bool
+	indexOptAlgo     string
 	indexOptMeta     string
 	indexOptArchive  bool
 	indexOptIgnores  []string
 	indexOptIndent   bool
 	indexOptForce    bool
 	indexOptNoMIME   bool
+	indexOptWorkers  int
+	indexOptIncr     bool
+	indexOptSearch   bool
+	indexOptColl     string
+	indexOptMetadata bool
+	indexOptContent  bool
+	indexOptContLim  string
 )
 
 func init() {
@@ -45,12 +56,20 @@ func init() {
 
 	indexCmd.PersistentFlags().StringSliceVarP(&indexOptTags, "tag", "t", nil, "add a tag")
 	indexCmd.PersistentFlags().BoolVarP(&indexOptChecksum, "checksum", "C", false, "calculate checksum")
+	indexCmd.PersistentFlags().StringVar(&indexOptAlgo, "checksum-algo", "", fmt.Sprintf("checksum algorithm, implies --checksum (%s, defaults to the one of the storage or %s)", strings.Join(helpers.ChecksumAlgos, ","), helpers.DefaultChecksumAlgo))
 	indexCmd.PersistentFlags().StringVarP(&indexOptMeta, "meta", "m", "", "meta information")
 	indexCmd.PersistentFlags().BoolVarP(&indexOptArchive, "archive", "a", false, "index archives")
 	indexCmd.PersistentFlags().StringSliceVarP(&indexOptIgnores, "ignore", "i", []string{}, "patterns to ignore")
 	indexCmd.PersistentFlags().BoolVarP(&indexOptIndent, "indent", "I", true, "do not indent json")
 	indexCmd.PersistentFlags().BoolVarP(&indexOptForce, "force", "f", false, "do not ask user")
 	indexCmd.PersistentFlags().BoolVarP(&indexOptNoMIME, "nomime", "M", false, "do not detect mime type")
+	indexCmd.PersistentFlags().IntVarP(&indexOptWorkers, "workers", "w", runtime.NumCPU(), "number of files/directories processed in parallel")
+	indexCmd.PersistentFlags().BoolVarP(&indexOptIncr, "incremental", "u", false, "only process the files that changed since last index")
+	indexCmd.PersistentFlags().BoolVar(&indexOptMetadata, "metadata", false, "extract metadata from media and documents (exif, audio tags, video duration, pdf info)")
+	indexCmd.PersistentFlags().BoolVar(&indexOptContent, "content", false, "index the text of text files, pdf and office documents in a full-text index beside the catalog")
+	indexCmd.PersistentFlags().StringVar(&indexOptContLim, "content-limit", "1M", "size of the largest file whose text is indexed")
+	indexCmd.PersistentFlags().StringVar(&indexOptColl, "collection", "", "collection of the storage (like \"LTO tapes/2023\")")
+	indexCmd.PersistentFlags().BoolVar(&indexOptSearch, "search-index", false, "build a search index beside the catalog (always updated once it exists)")
 }
 
 func index(_ *cobra.Command, args []string) error {
@@ -97,12 +116,40 @@ func index(_ *cobra.Command, args []string) error {
 		log.Debugf("creating new storage %s for path %s", name, path)
 		// get a new storage
 		top = node.NewStorageNode(name, path, filepath.Base(path), indexOptMeta, indexOptTags)
+		// a renamed storage may use the id derived from the name
+		top.ID = rootTree.NewStorageID(name)
 		// and append to tree
 		rootTree.Storages = append(rootTree.Storages, top)
 	}
+	if len(indexOptColl) > 0 {
+		top.Collection = node.CleanCollection(indexOptColl)
+	}
+
+	// checksum algorithm
+	var algo string
+	if indexOptChecksum || len(indexOptAlgo) > 0 {
+		algo = indexOptAlgo
+		if len(algo) < 1 && len(top.ChecksumAlgo) > 0 {
+			// keep the algorithm of the storage
+			algo = top.ChecksumAlgo
+		}
+		if len(algo) < 1 {
+			algo = helpers.DefaultChecksumAlgo
+		}
+		if !helpers.IsChecksumAlgo(algo) {
+			log.Fatal(fmt.Errorf("unsupported checksum algorithm \"%s\"", algo))
+		}
+		log.Debugf("checksum algorithm: %s", algo)
+	}
+
+	// full-text index
+	content, contentLimit, err := loadContent()
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	// walk the filesystem
-	w := walker.NewWalker(t, indexOptChecksum, indexOptArchive, ignPatterns, indexOptNoMIME)
+	w := walker.NewWalker(t, algo, indexOptArchive, ignPatterns, indexOptNoMIME, indexOptWorkers, indexOptIncr, indexOptMetadata, content, contentLimit)
 
 	t0 := time.Now()
 	// spinner
@@ -114,7 +161,7 @@ func index(_ *cobra.Command, args []string) error {
 		log.Warn(err.Error())
 	}
 
-	cnt, size, err := w.Walk(top.ID, path, top, spinner)
+	stats, size, err := w.Walk(top.ID, path, top, spinner)
 	if err == nil {
 		log.Debug("stop spinner...")
 		err := spinner.Stop()
@@ -126,12 +173,54 @@ func index(_ *cobra.Command, args []string) error {
 		if err != nil {
 			return err
 		}
+		if indexOptSearch || search.Exists(rootOptCatalogPath) {
+			log.Debug("updating search index...")
+			err = search.Build(t).Save(rootOptCatalogPath)
+			if err != nil {
+				return err
+			}
+		}
+		if content != nil {
+			log.Debug("updating full-text index...")
+			content.Prune(t, top)
+			err = content.Save(rootOptCatalogPath)
+			if err != nil {
+				return err
+			}
+		}
 		hsize := helpers.SizeToHuman(size)
-		log.Infof("\"%s\" indexed to \"%s\" (%d entries, %s in %v)", path, rootOptCatalogPath, cnt, hsize, time.Since(t0))
+		log.Infof("\"%s\" indexed to \"%s\" (%d entries, %s in %v)", path, rootOptCatalogPath, stats.Total, hsize, time.Since(t0))
+		log.Infof("%d added, %d modified, %d removed, %d unchanged", stats.Added, stats.Modified, stats.Removed, stats.Kept)
 	}
 	return err
 }
 
+// loadContent returns the full-text index to update (nil if
+// none) and the size of the largest file whose text is extracted
+// (zero to only drop the text of the files that changed)
+func loadContent() (*search.Content, int64, error) {
+	var limit int64
+	if indexOptContent {
+		size, err := helpers.HumanToSize(indexOptContLim)
+		if err != nil {
+			return nil, 0, err
+		}
+		limit = int64(size)
+	}
+
+	if !search.ContentExists(rootOptCatalogPath) {
+		if !indexOptContent {
+			return nil, 0, nil
+		}
+		return search.NewContent(), limit, nil
+	}
+	content, err := search.LoadContent(rootOptCatalogPath)
+	if err != nil {
+		return nil, 0, fmt.Errorf("full-text index: %v", err)
+	}
+	return content, limit, nil
+}
+
 func loadCatalog(storageName string, fsPath string) (*tree.Tree, *node.StorageNode, error) {
 	var top *node.StorageNode
 	var err error
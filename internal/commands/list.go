@@ -21,7 +21,7 @@ var (
 		Use:    "ls [<path>]",
 		Short:  "List catalog content",
 		Args:   cobra.MaximumNArgs(1),
-		PreRun: preRun(true),
+		PreRun: preRunLazy(true),
 		RunE:   list,
 	}
 
@@ -87,17 +87,17 @@ func listHierarchy(path string, format string, showAll bool, rawSize bool, long
 	if len(path) < 1 {
 		if maxDepth != 0 {
 			// list everything recursively
-			for _, top := range rootTree.GetStorages() {
+			for _, top := range rootTree.GetTops("") {
 				err := listPrint(stringGetter, top, showAll, maxDepth, true)
 				if err != nil {
 					log.Error(err)
 				}
 			}
 		} else {
-			// print the storages only
+			// print the collections and storages only
 			// we are intentionally not listing recursively
 			// when no storage is selected use find for that
-			for _, top := range rootTree.GetStorages() {
+			for _, top := range rootTree.GetTops("") {
 				stringGetter.Print(top, 0)
 			}
 		}
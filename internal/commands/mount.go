@@ -16,7 +16,7 @@ var (
 		Use:    "mount [<path>]",
 		Short:  "Mount catalog using fuse",
 		Args:   cobra.ExactArgs(1),
-		PreRun: preRun(true),
+		PreRun: preRunSnapshot(true),
 		RunE:   mount,
 	}
 )
@@ -19,7 +19,7 @@ var (
 	navCmd = &cobra.Command{
 		Use:    "nav [<path>]",
 		Short:  "Navigate catalog interactively",
-		PreRun: preRun(true),
+		PreRun: preRunLazy(true),
 		RunE:   nav,
 	}
 )
@@ -68,10 +68,10 @@ func callback(t *tree.Tree) func(string, bool, bool) (bool, []*stringer.Entry) {
 		}
 		printer := stringer.NewNativeStringer(t, m)
 		if len(path) < 1 {
-			// return all storages
-			log.Debugf("returning all storages...")
-			for _, storage := range t.Storages {
-				entry := printer.ToString(storage, 0)
+			// return all collections and storages
+			log.Debugf("returning all collections and storages...")
+			for _, top := range t.GetTops("") {
+				entry := printer.ToString(top, 0)
 				entries = append(entries, entry)
 			}
 			return true, entries
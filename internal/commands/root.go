@@ -6,6 +6,10 @@ Copyright (c) 2024, deadc0de6
 package commands
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -22,26 +26,33 @@ import (
 )
 
 var (
-	version     = "1.1.3"
-	myName      = "gocatcli"
-	defCatalog  = "gocatcli.catalog"
-	rootTree    *tree.Tree
-	rootCatalog *catalog.Catalog
-	separator   = ","
+	version           = "1.1.3"
+	myName            = "gocatcli"
+	defCatalog        = "gocatcli.catalog"
+	defCatalogBackups = 3
+	rootTree          *tree.Tree
+	rootCatalog       *catalog.Catalog
+	rootOthers        []*catalog.Catalog // other catalogs open until the command ends
+	separator         = ","
 
 	rootCmd = &cobra.Command{
-		Use:     "gocatcli",
-		Short:   "gocatcli - filesystem indexer",
-		Long:    `The command line catalog tool for your offline data`,
-		Version: version,
+		Use:               "gocatcli",
+		Short:             "gocatcli - filesystem indexer",
+		Long:              `The command line catalog tool for your offline data`,
+		Version:           version,
+		PersistentPostRun: postRun,
 	}
 
-	rootOptCatalogPath string
-	rootOptDebugMode   bool
-	rootOptNoColor     bool
+	rootOptCatalogPath  string
+	rootOptCatalogPaths []string
+	rootOptDebugMode    bool
+	rootOptNoColor      bool
+	rootOptBackups      int
 )
 
 func init() {
+	cobra.OnInitialize(initCatalogPaths)
+
 	// env variables
 	viper.SetEnvPrefix(strings.ToUpper(myName))
 	viper.SetEnvKeyReplacer(strings.NewReplacer("-", "_"))
@@ -52,9 +63,92 @@ func init() {
 	if len(defCatalogPath) < 1 {
 		defCatalogPath = defCatalog
 	}
-	rootCmd.PersistentFlags().StringVarP(&rootOptCatalogPath, "catalog", "c", defCatalogPath, "catalog file path")
+	hlp := "catalog file path (read-only commands accept multiple catalogs or a directory of catalogs)"
+	rootCmd.PersistentFlags().StringArrayVarP(&rootOptCatalogPaths, "catalog", "c", filepath.SplitList(defCatalogPath), hlp)
 	rootCmd.PersistentFlags().BoolVarP(&rootOptDebugMode, "debug", "d", viper.GetBool("DEBUG"), "enable debug mode")
 	rootCmd.PersistentFlags().BoolVar(&rootOptNoColor, "nocolor", false, "disable colors")
+	defBackups := defCatalogBackups
+	if viper.IsSet("BACKUPS") {
+		defBackups = viper.GetInt("BACKUPS")
+	}
+	rootCmd.PersistentFlags().IntVar(&rootOptBackups, "backups", defBackups, "number of previous catalog versions to keep (0 to disable)")
+}
+
+// initCatalogPaths expands the directories of catalogs
+func initCatalogPaths() {
+	var paths []string
+	for _, path := range rootOptCatalogPaths {
+		fi, err := os.Stat(path)
+		if err != nil || !fi.IsDir() {
+			paths = append(paths, path)
+			continue
+		}
+		found, err := catalog.FindCatalogs(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(found) < 1 {
+			log.Fatalf("no catalog found in %s", path)
+		}
+		paths = append(paths, found...)
+	}
+	rootOptCatalogPaths = paths
+	if len(paths) > 0 {
+		rootOptCatalogPath = paths[0]
+	}
+}
+
+// isFederated returns true when multiple catalogs are used
+func isFederated() bool {
+	return len(rootOptCatalogPaths) > 1
+}
+
+// loadFederatedTree loads all the catalogs in a single tree
+func loadFederatedTree(mode loadMode) (*tree.Tree, error) {
+	var names []string
+	var trees []*tree.Tree
+	for _, path := range rootOptCatalogPaths {
+		t, err := loadReadOnlyCatalog(path, mode)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+		names = append(names, catalog.Name(path))
+		trees = append(trees, t)
+	}
+	return tree.Federate(names, trees), nil
+}
+
+// loadOtherCatalog loads the entire tree of a catalog other than the root one
+func loadOtherCatalog(path string) (*tree.Tree, error) {
+	return loadReadOnlyCatalog(path, loadSnapshot)
+}
+
+// loadReadOnlyCatalog loads a catalog other than the root one,
+// with loadRead its nodes are loaded on demand and the catalog
+// stays open until the command ends
+func loadReadOnlyCatalog(path string, mode loadMode) (*tree.Tree, error) {
+	if !helpers.FileExists(path) {
+		return nil, fmt.Errorf("catalog not found %s", path)
+	}
+	c, err := catalog.NewCatalog(path)
+	if err != nil {
+		return nil, err
+	}
+	err = c.Lock(false)
+	if err != nil {
+		return nil, err
+	}
+	if mode == loadRead {
+		rootOthers = append(rootOthers, c)
+		return c.LoadTreeLazy()
+	}
+	defer func() {
+		err := c.Unlock()
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+	return c.LoadTree()
 }
 
 func preRunDebug(*cobra.Command, []string) {
@@ -63,7 +157,37 @@ func preRunDebug(*cobra.Command, []string) {
 	}
 }
 
+// catalog loading modes
+type loadMode int
+
+const (
+	// entire tree, catalog locked exclusively until the command ends
+	loadWrite loadMode = iota
+	// nodes loaded on demand, catalog shared until the command ends
+	loadRead
+	// entire tree, catalog shared only while loading
+	loadSnapshot
+)
+
+// preRun loads the entire catalog tree
+// for commands modifying the catalog
 func preRun(loadCatalogFatal bool) func(*cobra.Command, []string) {
+	return loadRootCatalog(loadCatalogFatal, loadWrite)
+}
+
+// preRunLazy loads the catalog tree for read-only commands,
+// nodes are loaded on demand when the backend supports it
+func preRunLazy(loadCatalogFatal bool) func(*cobra.Command, []string) {
+	return loadRootCatalog(loadCatalogFatal, loadRead)
+}
+
+// preRunSnapshot loads the entire catalog tree for
+// long running read-only commands
+func preRunSnapshot(loadCatalogFatal bool) func(*cobra.Command, []string) {
+	return loadRootCatalog(loadCatalogFatal, loadSnapshot)
+}
+
+func loadRootCatalog(loadCatalogFatal bool, mode loadMode) func(*cobra.Command, []string) {
 	return func(ccmd *cobra.Command, args []string) {
 		var err error
 
@@ -74,11 +198,33 @@ func preRun(loadCatalogFatal bool) func(*cobra.Command, []string) {
 			colorme.UseColors = false
 		}
 
+		if isFederated() {
+			if mode == loadWrite {
+				log.Fatalf("multiple catalogs are only supported by read-only commands")
+			}
+			rootTree, err = loadFederatedTree(mode)
+			if err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+
 		// check catalog file path
 		if !helpers.FileExists(rootOptCatalogPath) && loadCatalogFatal {
 			log.Fatalf("catalog not found %s", rootOptCatalogPath)
 		}
 
+		// lock catalog
+		rootCatalog = newRootCatalog()
+		err = rootCatalog.Lock(mode == loadWrite)
+		if err != nil && mode == loadWrite {
+			log.Fatal(err)
+		}
+		if err != nil {
+			// catalog on read-only location
+			log.Debugf("%v, reading without lock", err)
+		}
+
 		// spinner
 		s := spinner.New(spinner.CharSets[24], 100*time.Millisecond)
 		s.Suffix = " loading catalog..."
@@ -92,14 +238,52 @@ func preRun(loadCatalogFatal bool) func(*cobra.Command, []string) {
 		}()
 
 		// load catalog
-		rootCatalog = catalog.NewCatalog(rootOptCatalogPath)
-		if rootCatalog == nil {
-			log.Fatalf("cannot construct catalog")
+		if mode == loadRead {
+			rootTree, err = rootCatalog.LoadTreeLazy()
+		} else {
+			rootTree, err = rootCatalog.LoadTree()
 		}
-		rootTree, err = rootCatalog.LoadTree()
-		if err != nil && loadCatalogFatal {
+		if err != nil && (loadCatalogFatal || errors.Is(err, catalog.ErrSchemaTooRecent)) {
+			// never overwrite a catalog we do not understand
 			log.Fatal(err)
 		}
+
+		if mode == loadSnapshot {
+			err = rootCatalog.Unlock()
+			if err != nil {
+				log.Error(err)
+			}
+		}
+	}
+}
+
+// newRootCatalog constructs the catalog pointed by the catalog option
+func newRootCatalog() *catalog.Catalog {
+	if isFederated() {
+		log.Fatalf("multiple catalogs are only supported by read-only commands")
+	}
+	c, err := catalog.NewCatalog(rootOptCatalogPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	c.Backups = rootOptBackups
+	return c
+}
+
+func postRun(*cobra.Command, []string) {
+	catalogs := rootOthers
+	if rootCatalog != nil {
+		catalogs = append(catalogs, rootCatalog)
+	}
+	for _, c := range catalogs {
+		err := c.Close()
+		if err != nil {
+			log.Error(err)
+		}
+		err = c.Unlock()
+		if err != nil {
+			log.Error(err)
+		}
 	}
 }
 
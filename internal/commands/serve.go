@@ -0,0 +1,59 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package commands
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/deadc0de6/gocatcli/internal/log"
+	"github.com/deadc0de6/gocatcli/internal/server"
+	"github.com/deadc0de6/gocatcli/internal/tree"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	serveCmd = &cobra.Command{
+		Use:    "serve",
+		Short:  "Serve the catalog read-only over http",
+		Long:   "Serve a web ui and a json api (/api/storages, /api/ls, /api/node, /api/find, /api/du) to browse the catalog, the catalog is reloaded when it changes on disk",
+		Args:   cobra.NoArgs,
+		PreRun: preRunSnapshot(true),
+		RunE:   serve,
+	}
+
+	serveOptAddress    string
+	serveOptReload     time.Duration
+	serveOptMaxResults int
+)
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+
+	serveCmd.PersistentFlags().StringVarP(&serveOptAddress, "address", "a", "127.0.0.1:8080", "address to listen on")
+	serveCmd.PersistentFlags().DurationVarP(&serveOptReload, "reload", "r", 5*time.Second, "interval to check the catalog for changes (0 to disable)")
+	serveCmd.PersistentFlags().IntVarP(&serveOptMaxResults, "max-results", "m", server.DefaultMaxResults, "max number of entries returned by find")
+}
+
+func serve(_ *cobra.Command, _ []string) error {
+	loader := func() (*tree.Tree, error) {
+		if isFederated() {
+			return loadFederatedTree(loadSnapshot)
+		}
+		return loadOtherCatalog(rootOptCatalogPath)
+	}
+	srv := server.NewServer(rootOptCatalogPaths, rootTree, loader)
+	srv.MaxResults = serveOptMaxResults
+
+	if serveOptReload > 0 {
+		go srv.Watch(serveOptReload)
+	}
+
+	log.Infof("serving \"%s\" on http://%s", strings.Join(rootOptCatalogPaths, ","), serveOptAddress)
+	return http.ListenAndServe(serveOptAddress, srv.Handler())
+}
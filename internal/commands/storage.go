@@ -7,9 +7,11 @@ package commands
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/deadc0de6/gocatcli/internal/helpers"
 	"github.com/deadc0de6/gocatcli/internal/log"
+	"github.com/deadc0de6/gocatcli/internal/node"
 	"github.com/deadc0de6/gocatcli/internal/stringer"
 
 	"github.com/spf13/cobra"
@@ -24,7 +26,7 @@ var (
 	storageListCmd = &cobra.Command{
 		Use:    "list",
 		Short:  "List storages",
-		PreRun: preRun(true),
+		PreRun: preRunLazy(true),
 		RunE:   storageList,
 	}
 
@@ -60,6 +62,22 @@ var (
 		RunE:   storageUntag,
 	}
 
+	storageRenameCmd = &cobra.Command{
+		Use:    "rename <storage-name> <new-name>",
+		Short:  "Rename a storage",
+		Args:   cobra.ExactArgs(2),
+		PreRun: preRun(true),
+		RunE:   storageRename,
+	}
+
+	storageMoveCmd = &cobra.Command{
+		Use:    "move <storage-name> [<collection>]",
+		Short:  "Move a storage to a collection (like \"LTO tapes/2023\") or out of it",
+		Args:   cobra.RangeArgs(1, 2),
+		PreRun: preRun(true),
+		RunE:   storageMove,
+	}
+
 	storageOptIndent  bool
 	storageRmOptForce bool
 )
@@ -70,6 +88,8 @@ func init() {
 	storageCmd.AddCommand(storageTagCmd)
 	storageCmd.AddCommand(storageUntagCmd)
 	storageCmd.AddCommand(storageListCmd)
+	storageCmd.AddCommand(storageRenameCmd)
+	storageCmd.AddCommand(storageMoveCmd)
 
 	rootCmd.AddCommand(storageCmd)
 
@@ -78,6 +98,8 @@ func init() {
 	storageMetaCmd.PersistentFlags().BoolVarP(&storageOptIndent, "indent", "I", true, "do not indent json")
 	storageTagCmd.PersistentFlags().BoolVarP(&storageOptIndent, "indent", "I", true, "do not indent json")
 	storageUntagCmd.PersistentFlags().BoolVarP(&storageOptIndent, "indent", "I", true, "do not indent json")
+	storageRenameCmd.PersistentFlags().BoolVarP(&storageOptIndent, "indent", "I", true, "do not indent json")
+	storageMoveCmd.PersistentFlags().BoolVarP(&storageOptIndent, "indent", "I", true, "do not indent json")
 
 	// rm options
 	storageRemoveCmd.PersistentFlags().BoolVarP(&storageRmOptForce, "force", "f", false, "do not ask user")
@@ -144,6 +166,45 @@ func storageUntag(_ *cobra.Command, args []string) error {
 	return ret
 }
 
+func storageRename(_ *cobra.Command, args []string) error {
+	name := args[0]
+	newName := strings.TrimSpace(args[1])
+
+	storage := rootTree.GetStorageByName(name)
+	if storage == nil {
+		return fmt.Errorf("no such storage %s", name)
+	}
+	if len(newName) < 1 || strings.Contains(newName, node.CollectionSeparator) {
+		return fmt.Errorf("bad storage name \"%s\"", newName)
+	}
+	if rootTree.GetStorageByName(newName) != nil {
+		return fmt.Errorf("storage %s already exists", newName)
+	}
+
+	rootTree.RenameStorage(storage, newName)
+	ret := storageSave()
+	listStorages()
+	return ret
+}
+
+func storageMove(_ *cobra.Command, args []string) error {
+	name := args[0]
+	var collection string
+	if len(args) > 1 {
+		collection = node.CleanCollection(args[1])
+	}
+
+	storage := rootTree.GetStorageByName(name)
+	if storage == nil {
+		return fmt.Errorf("no such storage %s", name)
+	}
+
+	storage.Collection = collection
+	ret := storageSave()
+	listStorages()
+	return ret
+}
+
 func listStorages() {
 	storages := rootTree.GetStorages()
 	if storages == nil {
@@ -6,6 +6,10 @@ Copyright (c) 2024, deadc0de6
 package commands
 
 import (
+	"fmt"
+	"slices"
+	"strings"
+
 	"github.com/deadc0de6/gocatcli/internal/stringer"
 
 	"github.com/spf13/cobra"
@@ -15,7 +19,7 @@ var (
 	treeCmd = &cobra.Command{
 		Use:    "tree [<path>]",
 		Short:  "List catalog content as a tree",
-		PreRun: preRun(true),
+		PreRun: preRunLazy(true),
 		RunE:   treeView,
 	}
 
@@ -23,6 +27,9 @@ var (
 	treeOptRawSize bool
 	treeOptLong    bool
 	treeOptDepth   int
+	treeOptFormat  string
+
+	treeFormats = []string{stringer.FormatTree, stringer.FormatJSON, stringer.FormatNDJSON}
 )
 
 func init() {
@@ -32,12 +39,17 @@ func init() {
 	treeCmd.PersistentFlags().BoolVarP(&treeOptRawSize, "raw-size", "S", false, "do not humanize sizes when printing")
 	treeCmd.PersistentFlags().BoolVarP(&treeOptLong, "long", "l", false, "long listing format")
 	treeCmd.PersistentFlags().IntVarP(&treeOptDepth, "depth", "D", -1, "max depth")
+	hlp := fmt.Sprintf("output format (%s)", strings.Join(treeFormats, ","))
+	treeCmd.PersistentFlags().StringVarP(&treeOptFormat, "format", "f", stringer.FormatTree, hlp)
 }
 
 func treeView(_ *cobra.Command, args []string) error {
+	if !slices.Contains(treeFormats, treeOptFormat) {
+		return fmt.Errorf("unsupported format %s", treeOptFormat)
+	}
 	var path string
 	if len(args) > 0 {
 		path = args[0]
 	}
-	return ls(path, stringer.FormatTree, treeOptLong, treeOptRawSize, treeOptShowAll, treeOptDepth, true)
+	return ls(path, treeOptFormat, treeOptLong, treeOptRawSize, treeOptShowAll, treeOptDepth, true)
 }
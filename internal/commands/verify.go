@@ -0,0 +1,141 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+
+	"github.com/deadc0de6/gocatcli/internal/colorme"
+	"github.com/deadc0de6/gocatcli/internal/differ"
+	"github.com/deadc0de6/gocatcli/internal/helpers"
+	"github.com/deadc0de6/gocatcli/internal/log"
+	"github.com/deadc0de6/gocatcli/internal/node"
+	"github.com/deadc0de6/gocatcli/internal/tree"
+	"github.com/deadc0de6/gocatcli/internal/walker"
+
+	"github.com/pterm/pterm"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyCmd = &cobra.Command{
+		Use:    "verify <storage> <path>",
+		Short:  "Verify a storage against the filesystem",
+		Long:   "Compare a storage with the filesystem at path and report missing, new, modified and corrupted files, exit with an error on differences",
+		PreRun: preRunSnapshot(true),
+		Args:   cobra.ExactArgs(2),
+		RunE:   verify,
+	}
+
+	verifyOptIgnores []string
+	verifyOptQuick   bool
+	verifyOptWorkers int
+)
+
+func init() {
+	rootCmd.AddCommand(verifyCmd)
+
+	verifyCmd.PersistentFlags().StringSliceVarP(&verifyOptIgnores, "ignore", "i", []string{}, "patterns to ignore")
+	verifyCmd.PersistentFlags().BoolVarP(&verifyOptQuick, "quick", "q", false, "do not verify checksums")
+	verifyCmd.PersistentFlags().IntVarP(&verifyOptWorkers, "workers", "w", runtime.NumCPU(), "number of files/directories processed in parallel")
+}
+
+func verify(_ *cobra.Command, args []string) error {
+	name := args[0]
+	path, err := filepath.Abs(args[1])
+	if err != nil {
+		return err
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("\"%s\" is not a directory", path)
+	}
+
+	storage := rootTree.GetStorageByName(name)
+	if storage == nil {
+		return fmt.Errorf("no such storage \"%s\"", name)
+	}
+
+	var ignPatterns []*regexp.Regexp
+	for _, ign := range verifyOptIgnores {
+		re, err := regexp.Compile(helpers.PatchPattern(ign))
+		if err != nil {
+			return err
+		}
+		ignPatterns = append(ignPatterns, re)
+	}
+
+	algo := storage.ChecksumAlgo
+	if verifyOptQuick {
+		algo = ""
+	}
+	log.Debugf("verifying \"%s\" against \"%s\" (checksum: %s)", name, path, algo)
+
+	// index the filesystem in a separate tree
+	// to leave the catalog untouched
+	t, err := tree.NewTree(version)
+	if err != nil {
+		return err
+	}
+	live := node.NewStorageNode(storage.Name, path, filepath.Base(path), "", nil)
+	live.ID = storage.ID
+	t.Storages = append(t.Storages, live)
+
+	spinner := pterm.DefaultSpinner.WithRemoveWhenDone(true)
+	spinner.Sequence = []string{` ⠋ `, ` ⠙ `, ` ⠹ `, ` ⠸ `, ` ⠼ `, ` ⠴ `, ` ⠦ `, ` ⠧ `, ` ⠇ `, ` ⠏ `}
+	spinner.ShowTimer = true
+	spinner, err = spinner.Start(fmt.Sprintf("verifying %s", path))
+	if err != nil {
+		log.Warn(err.Error())
+	}
+	w := walker.NewWalker(t, algo, false, ignPatterns, true, verifyOptWorkers, false, false, nil, 0)
+	stats, _, err := w.Walk(live.ID, path, live, spinner)
+	if spinner != nil {
+		serr := spinner.Stop()
+		if serr != nil {
+			log.Error(serr)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	changes := differ.Compare(storage, live)
+	cnts := make(map[string]int)
+	cm := colorme.NewColorme(false)
+	for _, change := range changes {
+		cnts[change.Type]++
+		status := fmt.Sprintf("%-9s", change.Type)
+		switch change.Type {
+		case differ.ChangeMissing, differ.ChangeCorrupted:
+			status = cm.InRed(status)
+		case differ.ChangeNew:
+			status = cm.InGreen(status)
+		default:
+			status = cm.InYellow(status)
+		}
+		line := fmt.Sprintf("%s %s", status, filepath.Join(storage.GetName(), change.Path))
+		if len(change.Reasons) > 0 {
+			line += cm.InGray(fmt.Sprintf(" (%s)", strings.Join(change.Reasons, ",")))
+		}
+		fmt.Println(line)
+	}
+
+	log.Infof("%d entries verified: %d missing, %d new, %d modified, %d corrupted", stats.Total,
+		cnts[differ.ChangeMissing], cnts[differ.ChangeNew], cnts[differ.ChangeModified], cnts[differ.ChangeCorrupted])
+	if len(changes) > 0 {
+		log.Fatalf("storage \"%s\" differs from \"%s\"", name, path)
+	}
+	return nil
+}
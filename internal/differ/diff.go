@@ -0,0 +1,185 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package differ
+
+import (
+	"sort"
+
+	"github.com/deadc0de6/gocatcli/internal/node"
+	"github.com/deadc0de6/gocatcli/internal/tree"
+)
+
+const (
+	// ChangeAdded entry added to the storage
+	ChangeAdded = "added"
+	// ChangeRemoved entry removed from the storage
+	ChangeRemoved = "removed"
+	// ChangeMoved file found at another path with the same checksum
+	ChangeMoved = "moved"
+	// ChangeChanged entry changed
+	ChangeChanged = "changed"
+)
+
+// StorageDiff the differences of a storage between two trees
+type StorageDiff struct {
+	Name    string
+	Old     *node.StorageNode // nil if added
+	New     *node.StorageNode // nil if removed
+	Changes []*Change
+}
+
+// SizeDelta returns the size difference of the storage
+func (d *StorageDiff) SizeDelta() int64 {
+	var oldSize, newSize uint64
+	if d.Old != nil {
+		oldSize = d.Old.GetSize()
+	}
+	if d.New != nil {
+		newSize = d.New.GetSize()
+	}
+	return int64(newSize) - int64(oldSize)
+}
+
+// DiffTrees compares the storages of two trees, storages
+// are matched by name. If names is not empty, only those
+// storages are compared
+func DiffTrees(oldTree *tree.Tree, newTree *tree.Tree, names []string) []*StorageDiff {
+	var diffs []*StorageDiff
+	selected := func(name string) bool {
+		if len(names) < 1 {
+			return true
+		}
+		for _, n := range names {
+			if n == name {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, oldSto := range oldTree.GetStorages() {
+		if !selected(oldSto.GetName()) {
+			continue
+		}
+		d := &StorageDiff{
+			Name: oldSto.GetName(),
+			Old:  oldSto,
+			New:  newTree.GetStorageByName(oldSto.GetName()),
+		}
+		if d.New == nil {
+			diffs = append(diffs, d)
+			continue
+		}
+		d.Changes = Diff(oldSto, d.New)
+		if len(d.Changes) > 0 || d.SizeDelta() != 0 {
+			diffs = append(diffs, d)
+		}
+	}
+
+	for _, newSto := range newTree.GetStorages() {
+		if !selected(newSto.GetName()) {
+			continue
+		}
+		if oldTree.GetStorageByName(newSto.GetName()) != nil {
+			continue
+		}
+		diffs = append(diffs, &StorageDiff{
+			Name: newSto.GetName(),
+			New:  newSto,
+		})
+	}
+
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Name < diffs[j].Name
+	})
+	return diffs
+}
+
+// Diff compares two revisions of a hierarchy, unlike Compare
+// every removed and added entry is listed and files found
+// at another path with the same checksum are reported as moved
+func Diff(oldNode node.Node, newNode node.Node) []*Change {
+	var changes []*Change
+	for _, c := range Compare(oldNode, newNode) {
+		switch c.Type {
+		case ChangeMissing:
+			changes = append(changes, expand(c.Old, ChangeRemoved)...)
+		case ChangeNew:
+			changes = append(changes, expand(c.New, ChangeAdded)...)
+		default:
+			c.Type = ChangeChanged
+			changes = append(changes, c)
+		}
+	}
+	changes = detectMoves(changes)
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+// expand returns a change for n and each of its children
+func expand(n *node.FileNode, typ string) []*Change {
+	c := &Change{
+		Type: typ,
+		Path: n.GetPath(),
+	}
+	if typ == ChangeRemoved {
+		c.Old = n
+	} else {
+		c.New = n
+	}
+	changes := []*Change{c}
+	for _, child := range n.GetSortedDirectChildren() {
+		if child.GetType() == node.FileTypeArchived {
+			continue
+		}
+		changes = append(changes, expand(child, typ)...)
+	}
+	return changes
+}
+
+// detectMoves pairs removed and added files with the same checksum
+func detectMoves(changes []*Change) []*Change {
+	added := make(map[string][]*Change)
+	for _, c := range changes {
+		if c.Type != ChangeAdded || node.IsDir(c.New) || len(c.New.Checksum) < 1 {
+			continue
+		}
+		added[c.New.Checksum] = append(added[c.New.Checksum], c)
+	}
+
+	paired := make(map[*Change]bool)
+	var moves []*Change
+	for _, c := range changes {
+		if c.Type != ChangeRemoved || node.IsDir(c.Old) || len(c.Old.Checksum) < 1 {
+			continue
+		}
+		candidates := added[c.Old.Checksum]
+		if len(candidates) < 1 {
+			continue
+		}
+		target := candidates[0]
+		added[c.Old.Checksum] = candidates[1:]
+		paired[c] = true
+		paired[target] = true
+		moves = append(moves, &Change{
+			Type: ChangeMoved,
+			Path: target.Path,
+			Old:  c.Old,
+			New:  target.New,
+		})
+	}
+
+	var out []*Change
+	for _, c := range changes {
+		if !paired[c] {
+			out = append(out, c)
+		}
+	}
+	return append(out, moves...)
+}
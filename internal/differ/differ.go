@@ -0,0 +1,147 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package differ
+
+import (
+	"sort"
+
+	"github.com/deadc0de6/gocatcli/internal/helpers"
+	"github.com/deadc0de6/gocatcli/internal/log"
+	"github.com/deadc0de6/gocatcli/internal/node"
+)
+
+const (
+	// ChangeMissing entry only found in the reference
+	ChangeMissing = "missing"
+	// ChangeNew entry not found in the reference
+	ChangeNew = "new"
+	// ChangeModified entry metadata changed
+	ChangeModified = "modified"
+	// ChangeCorrupted same metadata but different checksum
+	ChangeCorrupted = "corrupted"
+
+	reasonType     = "type"
+	reasonSize     = "size"
+	reasonMaccess  = "maccess"
+	reasonMode     = "mode"
+	reasonChecksum = "checksum"
+)
+
+// Change a difference between two hierarchies
+type Change struct {
+	Type    string
+	Path    string         // relative to the storage
+	Old     *node.FileNode // entry in the reference
+	New     *node.FileNode // entry in the compared hierarchy
+	Reasons []string       // fields that differ
+}
+
+// Compare compares the entries under ref with the ones under other,
+// for missing and new directories only the directory is reported.
+// Archived entries are ignored
+func Compare(ref node.Node, other node.Node) []*Change {
+	var changes []*Change
+	refChildren := ref.GetDirectChildren()
+	otherChildren := other.GetDirectChildren()
+
+	for name, left := range refChildren {
+		if left.GetType() == node.FileTypeArchived {
+			continue
+		}
+		right, ok := otherChildren[name]
+		if !ok {
+			changes = append(changes, &Change{
+				Type: ChangeMissing,
+				Path: left.GetPath(),
+				Old:  left,
+			})
+			continue
+		}
+		changes = append(changes, compareNodes(left, right)...)
+	}
+
+	for name, right := range otherChildren {
+		if right.GetType() == node.FileTypeArchived {
+			continue
+		}
+		if _, ok := refChildren[name]; ok {
+			continue
+		}
+		changes = append(changes, &Change{
+			Type: ChangeNew,
+			Path: right.GetPath(),
+			New:  right,
+		})
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes
+}
+
+func compareNodes(left *node.FileNode, right *node.FileNode) []*Change {
+	leftDir := node.IsDir(left)
+	rightDir := node.IsDir(right)
+	if leftDir != rightDir {
+		return []*Change{{
+			Type:    ChangeModified,
+			Path:    left.GetPath(),
+			Old:     left,
+			New:     right,
+			Reasons: []string{reasonType},
+		}}
+	}
+	if leftDir {
+		return Compare(left, right)
+	}
+
+	var reasons []string
+	if left.Size != right.Size {
+		reasons = append(reasons, reasonSize)
+	}
+	if left.Maccess != right.Maccess {
+		reasons = append(reasons, reasonMaccess)
+	}
+	if len(left.Mode) > 0 && len(right.Mode) > 0 && left.Mode != right.Mode {
+		reasons = append(reasons, reasonMode)
+	}
+	checksumDiffers := checksumsDiffer(left.Checksum, right.Checksum)
+	if checksumDiffers {
+		reasons = append(reasons, reasonChecksum)
+	}
+	if len(reasons) < 1 {
+		return nil
+	}
+
+	typ := ChangeModified
+	if checksumDiffers && left.Size == right.Size && left.Maccess == right.Maccess {
+		// content changed without its metadata
+		typ = ChangeCorrupted
+	}
+	log.Debugf("\"%s\" %s: %v", left.GetPath(), typ, reasons)
+	return []*Change{{
+		Type:    typ,
+		Path:    left.GetPath(),
+		Old:     left,
+		New:     right,
+		Reasons: reasons,
+	}}
+}
+
+// checksums can only be compared when
+// both exist and use the same algorithm
+func checksumsDiffer(left string, right string) bool {
+	if len(left) < 1 || len(right) < 1 {
+		return false
+	}
+	leftAlgo, _ := helpers.SplitChecksum(left)
+	rightAlgo, _ := helpers.SplitChecksum(right)
+	if leftAlgo != rightAlgo {
+		return false
+	}
+	return left != right
+}
@@ -0,0 +1,352 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package exporter
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/deadc0de6/gocatcli/internal/helpers"
+	"github.com/deadc0de6/gocatcli/internal/log"
+	"github.com/deadc0de6/gocatcli/internal/node"
+	"github.com/deadc0de6/gocatcli/internal/tree"
+)
+
+const (
+	pagesDir   = "pages"
+	indexPage  = "index.html"
+	searchData = "search-data.js"
+)
+
+var (
+	//go:embed html
+	htmlAssets embed.FS
+
+	// storage attributes shown on the summary, in order
+	storageAttrs = []struct {
+		key   string
+		title string
+	}{
+		{"size", "size"},
+		{"nbfiles", "files"},
+		{"fs_size", "filesystem size"},
+		{"fs_free", "free"},
+		{"tags", "tags"},
+		{"meta", "meta"},
+		{"checksum", "checksum"},
+		{"indexed", "indexed"},
+	}
+)
+
+// htmlPage a directory-like node with its own page
+type htmlPage struct {
+	id       int
+	node     node.Node
+	parent   *htmlPage
+	children []node.Node
+}
+
+// htmlLink a link of the breadcrumbs
+type htmlLink struct {
+	Name string
+	Link string
+}
+
+// htmlAttr a storage attribute
+type htmlAttr struct {
+	Key   string
+	Value string
+}
+
+// htmlRow a row of a listing
+type htmlRow struct {
+	Name  string
+	Link  string
+	Type  string
+	Size  string
+	Date  string
+	Free  string
+	Files string
+	Tags  string
+}
+
+// htmlContext the data a page is rendered with
+type htmlContext struct {
+	Title       string
+	Catalog     string
+	Root        string
+	Breadcrumbs []htmlLink
+	Storage     []htmlAttr
+	Path        string
+	IsIndex     bool
+	Rows        []htmlRow
+	Generated   string
+}
+
+// HTMLExporter exports the catalog to a static website
+type HTMLExporter struct {
+	theTree *tree.Tree
+	catalog string
+	showAll bool
+	tmpl    *template.Template
+	pages   map[node.Node]*htmlPage
+	ordered []*htmlPage
+	entries [][]any
+	now     string
+}
+
+// page returns the page of a node, nil if it has none
+func (e *HTMLExporter) page(n node.Node) *htmlPage {
+	return e.pages[n]
+}
+
+func (e *HTMLExporter) addPage(n node.Node, parent *htmlPage) *htmlPage {
+	p := &htmlPage{
+		id:     len(e.ordered),
+		node:   n,
+		parent: parent,
+	}
+	e.pages[n] = p
+	e.ordered = append(e.ordered, p)
+	return p
+}
+
+// fullPath returns storage/path of a node
+func (e *HTMLExporter) fullPath(n node.Node) string {
+	if node.IsCollection(n) {
+		return n.GetPath()
+	}
+	sto := e.theTree.GetStorageNode(n)
+	if sto == nil || node.IsStorage(n) {
+		return n.GetName()
+	}
+	return filepath.Join(sto.GetName(), n.GetPath())
+}
+
+// collect walks the tree from the start nodes and
+// registers the pages and the search entries
+func (e *HTMLExporter) collect(starts []node.Node) {
+	for _, start := range starts {
+		var top *htmlPage
+		if node.MayHaveChildren(start) {
+			top = e.addPage(start, nil)
+		}
+		e.addEntry(start, top)
+
+		callback := func(n node.Node, _ int, parent node.Node) bool {
+			pp := e.page(parent)
+			if pp == nil {
+				return true
+			}
+			pp.children = append(pp.children, n)
+			if node.MayHaveChildren(n) {
+				e.addPage(n, pp)
+			}
+			e.addEntry(n, pp)
+			return true
+		}
+		e.theTree.ProcessChildren(start, e.showAll, callback, -1)
+	}
+	log.Debugf("html export: %d page(s) and %d entries", len(e.ordered), len(e.entries))
+}
+
+// addEntry adds a node to the search data
+func (e *HTMLExporter) addEntry(n node.Node, container *htmlPage) {
+	link := -1
+	if p := e.page(n); p != nil {
+		link = p.id
+	} else if container != nil {
+		link = container.id
+	}
+	e.entries = append(e.entries, []any{
+		n.GetName(),
+		e.fullPath(n),
+		link,
+		string(n.GetType()),
+		helpers.SizeToHuman(n.GetSize()),
+	})
+}
+
+// link returns the link to the page of a node from the pages directory
+func (e *HTMLExporter) link(n node.Node) string {
+	p := e.page(n)
+	if p == nil {
+		return ""
+	}
+	return fmt.Sprintf("%d.html", p.id)
+}
+
+func (e *HTMLExporter) row(n node.Node, prefix string) htmlRow {
+	r := htmlRow{
+		Name: n.GetName(),
+		Type: string(n.GetType()),
+		Size: helpers.SizeToHuman(n.GetSize()),
+	}
+	if link := e.link(n); len(link) > 0 {
+		r.Link = prefix + link
+	}
+	if n.GetMAccess() > 0 {
+		r.Date = helpers.DateToString(n.GetMAccess())
+	}
+	if sto, ok := n.(*node.StorageNode); ok {
+		attrs := sto.GetAttr(false, true)
+		r.Date = attrs["indexed"]
+		r.Free = strings.TrimSpace(attrs["fs_free"])
+		r.Files = attrs["nbfiles"]
+		r.Tags = attrs["tags"]
+	}
+	return r
+}
+
+func (e *HTMLExporter) render(path string, ctx *htmlContext) error {
+	ctx.Catalog = e.catalog
+	ctx.Generated = e.now
+	fd, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	err = e.tmpl.ExecuteTemplate(fd, "page.html", ctx)
+	cerr := fd.Close()
+	if err != nil {
+		return err
+	}
+	return cerr
+}
+
+func (e *HTMLExporter) writePage(outDir string, p *htmlPage) error {
+	ctx := &htmlContext{
+		Title: e.fullPath(p.node),
+		Root:  "../",
+		Path:  e.fullPath(p.node),
+	}
+
+	// breadcrumbs from the top page
+	for cur := p; cur != nil; cur = cur.parent {
+		name := cur.node.GetName()
+		if cur.parent == nil {
+			name = e.fullPath(cur.node)
+		}
+		ctx.Breadcrumbs = append([]htmlLink{{Name: name, Link: e.link(cur.node)}}, ctx.Breadcrumbs...)
+	}
+
+	// storage summary
+	if sto, ok := p.node.(*node.StorageNode); ok {
+		attrs := sto.GetAttr(false, true)
+		ctx.Storage = append(ctx.Storage, htmlAttr{Key: "path", Value: sto.Path})
+		for _, a := range storageAttrs {
+			value := strings.TrimSpace(attrs[a.key])
+			if len(value) > 0 {
+				ctx.Storage = append(ctx.Storage, htmlAttr{Key: a.title, Value: value})
+			}
+		}
+	}
+
+	for _, child := range p.children {
+		ctx.Rows = append(ctx.Rows, e.row(child, ""))
+	}
+	return e.render(filepath.Join(outDir, pagesDir, fmt.Sprintf("%d.html", p.id)), ctx)
+}
+
+func (e *HTMLExporter) writeIndex(outDir string, starts []node.Node) error {
+	ctx := &htmlContext{
+		Title:   e.catalog,
+		IsIndex: true,
+	}
+	for _, start := range starts {
+		ctx.Rows = append(ctx.Rows, e.row(start, pagesDir+"/"))
+		if !node.IsStorage(start) {
+			ctx.Rows[len(ctx.Rows)-1].Name = e.fullPath(start)
+		}
+	}
+	return e.render(filepath.Join(outDir, indexPage), ctx)
+}
+
+func (e *HTMLExporter) writeSearchData(outDir string) error {
+	content, err := json.Marshal(e.entries)
+	if err != nil {
+		return err
+	}
+	data := fmt.Sprintf("var gocatcliEntries = %s;\n", content)
+	return os.WriteFile(filepath.Join(outDir, searchData), []byte(data), 0644)
+}
+
+func (e *HTMLExporter) writeAssets(outDir string) error {
+	for _, name := range []string{"style.css", "search.js"} {
+		content, err := htmlAssets.ReadFile("html/" + name)
+		if err != nil {
+			return err
+		}
+		err = os.WriteFile(filepath.Join(outDir, name), content, 0644)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Export writes the website of the start nodes to outDir
+func (e *HTMLExporter) Export(starts []node.Node, outDir string) error {
+	// remove the pages of a previous export
+	err := os.RemoveAll(filepath.Join(outDir, pagesDir))
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Join(outDir, pagesDir), 0755)
+	if err != nil {
+		return err
+	}
+
+	e.collect(starts)
+	for _, p := range e.ordered {
+		err = e.writePage(outDir, p)
+		if err != nil {
+			return err
+		}
+	}
+	err = e.writeIndex(outDir, starts)
+	if err != nil {
+		return err
+	}
+	err = e.writeSearchData(outDir)
+	if err != nil {
+		return err
+	}
+	err = e.writeAssets(outDir)
+	if err != nil {
+		return err
+	}
+	log.Debugf("html export written to \"%s\"", outDir)
+	return nil
+}
+
+// PageCount returns the number of directory pages exported
+func (e *HTMLExporter) PageCount() int {
+	return len(e.ordered)
+}
+
+// NewHTMLExporter creates a new html exporter, catalog
+// is the name shown as the root of the website
+func NewHTMLExporter(t *tree.Tree, catalog string, showAll bool) (*HTMLExporter, error) {
+	tmpl, err := template.ParseFS(htmlAssets, "html/page.html")
+	if err != nil {
+		return nil, err
+	}
+	e := HTMLExporter{
+		theTree: t,
+		catalog: catalog,
+		showAll: showAll,
+		tmpl:    tmpl,
+		pages:   make(map[node.Node]*htmlPage),
+		now:     time.Now().Format("2006-01-02 15:04:05"),
+	}
+	return &e, nil
+}
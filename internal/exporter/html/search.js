@@ -0,0 +1,73 @@
+// client-side search over the entries of search-data.js
+// each entry is [name, storage/path, page, type, size]
+(function () {
+  'use strict';
+
+  var maxResults = 500;
+  var root = document.body.getAttribute('data-root') || '';
+  var input = document.getElementById('search');
+  var results = document.getElementById('results');
+  var content = document.getElementById('content');
+  var entries = window.gocatcliEntries || [];
+
+  function cell(row, text) {
+    var td = document.createElement('td');
+    td.textContent = text;
+    row.appendChild(td);
+    return td;
+  }
+
+  function render(query) {
+    var terms = query.toLowerCase().split(/\s+/).filter(Boolean);
+    results.textContent = '';
+    if (terms.length === 0) {
+      results.hidden = true;
+      content.hidden = false;
+      return;
+    }
+
+    var table = document.createElement('table');
+    table.className = 'listing';
+    var cnt = 0;
+    for (var i = 0; i < entries.length; i++) {
+      var e = entries[i];
+      var path = e[1].toLowerCase();
+      var ok = terms.every(function (t) { return path.indexOf(t) !== -1; });
+      if (!ok) {
+        continue;
+      }
+      cnt++;
+      if (cnt > maxResults) {
+        continue;
+      }
+      var row = document.createElement('tr');
+      row.className = e[3];
+      var td = cell(row, '');
+      if (e[2] >= 0) {
+        var a = document.createElement('a');
+        a.href = root + 'pages/' + e[2] + '.html';
+        a.textContent = e[1];
+        td.appendChild(a);
+      } else {
+        td.textContent = e[1];
+      }
+      cell(row, e[3]);
+      cell(row, e[4]).className = 'num';
+      table.appendChild(row);
+    }
+
+    var title = document.createElement('h2');
+    title.textContent = cnt + ' result(s)' + (cnt > maxResults ? ', showing the first ' + maxResults : '');
+    results.appendChild(title);
+    results.appendChild(table);
+    results.hidden = false;
+    content.hidden = true;
+  }
+
+  input.addEventListener('input', function () {
+    render(input.value);
+  });
+  if (input.value) {
+    render(input.value);
+  }
+})();
@@ -42,6 +42,8 @@ func getNodeType(theNode node.Node) fuse.DirentType {
 		return fuse.DT_File
 	case node.FileTypeStorage:
 		return fuse.DT_Dir
+	case node.FileTypeCollection:
+		return fuse.DT_Dir
 	}
 	return fuse.DT_Unknown
 }
@@ -76,6 +78,15 @@ func nodeToFuse(theNode node.Node, theTree *tree.Tree, filesys *FS) fs.Node {
 	return sub
 }
 
+// getTops returns the collections and storages
+// found in this directory (root or collection)
+func (h *FuseDir) getTops() []node.Node {
+	if h.current == nil {
+		return h.theTree.GetTops("")
+	}
+	return h.theTree.GetTops(h.current.GetPath())
+}
+
 // Attr directory attributes
 func (h *FuseDir) Attr(_ context.Context, a *fuse.Attr) error {
 	if h == nil {
@@ -95,7 +106,7 @@ func (h *FuseDir) Attr(_ context.Context, a *fuse.Attr) error {
 		a.Ctime = time.Now()
 		a.Mode = os.ModeDir | 0755
 	} else {
-		a.Inode = helpers.HashString64(h.current.GetPath())
+		a.Inode = inode(h.current)
 		a.Mtime = time.Unix(h.current.GetMAccess(), 0)
 		a.Ctime = time.Unix(h.current.GetMAccess(), 0)
 		mode := iofs.FileMode(helpers.ModeStrToInt(h.current.GetMode()))
@@ -113,18 +124,14 @@ func (h *FuseDir) Lookup(_ context.Context, name string) (fs.Node, error) {
 		log.ToFile(logPath, line)
 	}
 
-	if h.current == nil {
-		// root
-		storage := h.theTree.GetStorageByName(name)
-		if storage == nil {
-			return nil, syscall.ENOENT
-		}
-		sub := &FuseDir{
-			theTree: h.theTree,
-			current: storage,
-			fs:      h.fs,
+	if h.current == nil || node.IsCollection(h.current) {
+		// root or collection
+		for _, top := range h.getTops() {
+			if top.GetName() == name {
+				return nodeToFuse(top, h.theTree, h.fs), nil
+			}
 		}
-		return sub, nil
+		return nil, syscall.ENOENT
 	}
 
 	// children
@@ -149,11 +156,10 @@ func (h *FuseDir) ReadDirAll(_ context.Context) ([]fuse.Dirent, error) {
 		log.ToFile(logPath, line)
 	}
 
-	if h.current == nil {
-		// root - list storages
-		storages := h.theTree.GetStorages()
-		for _, storage := range storages {
-			dirent := nodeToDirent(storage)
+	if h.current == nil || node.IsCollection(h.current) {
+		// root or collection - list collections and storages
+		for _, top := range h.getTops() {
+			dirent := nodeToDirent(top)
 			tops = append(tops, dirent)
 		}
 	} else {
@@ -33,7 +33,7 @@ func (h *FuseFile) Attr(_ context.Context, a *fuse.Attr) error {
 		log.ToFile(logPath, line)
 	}
 
-	a.Inode = helpers.HashString64(h.current.GetPath())
+	a.Inode = inode(h.current)
 	a.Mode = 0755
 	a.Size = h.current.GetSize()
 	a.Atime = time.Unix(h.current.GetMAccess(), 0)
@@ -6,7 +6,11 @@ Copyright (c) 2024, deadc0de6
 package fuser
 
 import (
+	"strconv"
+
+	"github.com/deadc0de6/gocatcli/internal/helpers"
 	"github.com/deadc0de6/gocatcli/internal/log"
+	"github.com/deadc0de6/gocatcli/internal/node"
 	"github.com/deadc0de6/gocatcli/internal/tree"
 
 	"github.com/anacrolix/fuse"
@@ -35,6 +39,20 @@ func (f *FS) Root() (fs.Node, error) {
 	return rd, nil
 }
 
+// inode returns a stable inode number for the node
+func inode(n node.Node) uint64 {
+	switch v := n.(type) {
+	case *node.StorageNode:
+		return helpers.HashString64(node.DeriveFileID(v.ID, ""))
+	case *node.FileNode:
+		ino, err := strconv.ParseUint(v.ID, 16, 64)
+		if err == nil {
+			return ino
+		}
+	}
+	return helpers.HashString64(n.GetPath())
+}
+
 // Mount mount the tree
 func Mount(theTree *tree.Tree, mountpoint string, debug bool) error {
 	c, err := fuse.Mount(
@@ -0,0 +1,106 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package helpers
+
+import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/deadc0de6/gocatcli/internal/log"
+
+	"github.com/cespare/xxhash/v2"
+	"golang.org/x/crypto/blake2b"
+	"lukechampine.com/blake3"
+)
+
+const (
+	// DefaultChecksumAlgo the default checksum algorithm
+	DefaultChecksumAlgo = "md5"
+	checksumSep         = ":"
+)
+
+var (
+	// ChecksumAlgos the supported checksum algorithms
+	ChecksumAlgos = []string{"md5", "sha1", "sha256", "blake2b", "blake3", "xxh64"}
+)
+
+func newHasher(algo string) (hash.Hash, error) {
+	switch algo {
+	case "md5":
+		return md5.New(), nil
+	case "sha1":
+		return sha1.New(), nil
+	case "sha256":
+		return sha256.New(), nil
+	case "blake2b":
+		return blake2b.New256(nil)
+	case "blake3":
+		return blake3.New(32, nil), nil
+	case "xxh64":
+		return xxhash.New(), nil
+	}
+	return nil, fmt.Errorf("unsupported checksum algorithm \"%s\"", algo)
+}
+
+// IsChecksumAlgo returns true if algo is supported
+func IsChecksumAlgo(algo string) bool {
+	_, err := newHasher(algo)
+	return err == nil
+}
+
+// ChecksumFileContent returns the checksum of file content
+// as "<algo>:<hex digest>"
+func ChecksumFileContent(path string, algo string) (string, error) {
+	h, err := newHasher(algo)
+	if err != nil {
+		return "", err
+	}
+	if !FileExists(path) {
+		return "", fmt.Errorf("%s does not exist", path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		err := f.Close()
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+
+	_, err = io.Copy(h, f)
+	if err != nil {
+		return "", err
+	}
+
+	return FormatChecksum(algo, hex.EncodeToString(h.Sum(nil))), nil
+}
+
+// FormatChecksum prefixes the digest with its algorithm
+func FormatChecksum(algo string, digest string) string {
+	if len(digest) < 1 {
+		return ""
+	}
+	return algo + checksumSep + digest
+}
+
+// SplitChecksum returns the algorithm and the digest of a checksum,
+// checksums without algorithm are md5
+func SplitChecksum(checksum string) (string, string) {
+	algo, digest, found := strings.Cut(checksum, checksumSep)
+	if !found {
+		return DefaultChecksumAlgo, checksum
+	}
+	return algo, digest
+}
@@ -6,15 +6,13 @@ Copyright (c) 2024, deadc0de6
 package helpers
 
 import (
-	"crypto/md5"
-	"encoding/hex"
 	"fmt"
 	"hash/fnv"
-	"io"
 	"math"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
@@ -23,38 +21,14 @@ import (
 	"github.com/pterm/pterm"
 )
 
+var humanSizeRe = regexp.MustCompile(`(?i)^([0-9]+(?:\.[0-9]+)?)([kmgtp]?)(?:i?b)?$`)
+
 // FileExists returns true if a file exists
 func FileExists(path string) bool {
 	_, err := os.Stat(path)
 	return !os.IsNotExist(err)
 }
 
-// ChecksumFileContent returns md5 checksum of file content
-func ChecksumFileContent(path string) (string, error) {
-	if !FileExists(path) {
-		return "", fmt.Errorf("%s does not exist", path)
-	}
-	f, err := os.Open(path)
-	if err != nil {
-		return "", err
-	}
-	defer func() {
-		err := f.Close()
-		if err != nil {
-			log.Error(err)
-		}
-	}()
-
-	h := md5.New()
-	_, err = io.Copy(h, f)
-	if err != nil {
-		return "", err
-	}
-
-	hash := h.Sum(nil)
-	return hex.EncodeToString(hash[:]), nil
-}
-
 // UniqStrings merges and uniq strings in slices
 func UniqStrings(slices ...[]string) []string {
 	uniq := make(map[string]bool)
@@ -106,6 +80,23 @@ func SizeToHuman(bytes uint64) string {
 	return fmt.Sprintf("%d%s", sz, unit)
 }
 
+// HumanToSize converts sizes like "1024", "10K" or "1.5GB" to bytes
+func HumanToSize(value string) (uint64, error) {
+	m := humanSizeRe.FindStringSubmatch(value)
+	if m == nil {
+		return 0, fmt.Errorf("invalid size \"%s\"", value)
+	}
+	num, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, err
+	}
+	exp := strings.Index("kmgtp", strings.ToLower(m[2])) + 1
+	if len(m[2]) < 1 {
+		exp = 0
+	}
+	return uint64(num * math.Pow(1024, float64(exp))), nil
+}
+
 // DateToString converts date to string
 func DateToString(seconds int64) string {
 	dt := time.Unix(seconds, 0)
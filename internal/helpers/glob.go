@@ -0,0 +1,56 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package helpers
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// DoubleStar matches any number of path components
+	DoubleStar = "**"
+)
+
+// HasDoubleStar returns true if the pattern contains a "**" component
+func HasDoubleStar(pattern string) bool {
+	for _, part := range strings.Split(pattern, string(filepath.Separator)) {
+		if part == DoubleStar {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchGlob matches path against pattern component by
+// component like filepath.Match, a "**" component matches
+// zero or more components
+func MatchGlob(pattern string, path string) bool {
+	patts := strings.Split(strings.Trim(pattern, string(filepath.Separator)), string(filepath.Separator))
+	parts := strings.Split(strings.Trim(path, string(filepath.Separator)), string(filepath.Separator))
+	return matchComponents(patts, parts)
+}
+
+func matchComponents(patts []string, parts []string) bool {
+	if len(patts) < 1 {
+		return len(parts) < 1
+	}
+	if patts[0] == DoubleStar {
+		// zero component or consume one
+		if matchComponents(patts[1:], parts) {
+			return true
+		}
+		return len(parts) > 0 && matchComponents(patts, parts[1:])
+	}
+	if len(parts) < 1 {
+		return false
+	}
+	matched, err := filepath.Match(patts[0], parts[0])
+	if err != nil || !matched {
+		return false
+	}
+	return matchComponents(patts[1:], parts[1:])
+}
@@ -250,7 +250,7 @@ func (a *Navigator) runApp(_ string) {
 				if entry.Node.GetType() == node.FileTypeArchive {
 					a.path = filepath.Join(a.path, entry.Name)
 					reload = true
-				} else if entry.Node.GetType() == node.FileTypeStorage {
+				} else if entry.Node.GetType() == node.FileTypeStorage || entry.Node.GetType() == node.FileTypeCollection {
 					a.path = filepath.Join(a.path, entry.Name)
 					reload = true
 				} else if entry.Node.GetType() == node.FileTypeDir {
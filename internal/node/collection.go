@@ -0,0 +1,114 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package node
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/deadc0de6/gocatcli/internal/helpers"
+)
+
+const (
+	// CollectionSeparator separates the levels of a collection
+	CollectionSeparator = "/"
+)
+
+// CollectionNode a virtual node grouping storages,
+// its content is provided by the tree
+type CollectionNode struct {
+	Path      string
+	Size      uint64
+	IndexedAt int64 // most recent storage indexing
+	Storages  int   // number of storages (recursively)
+}
+
+// CleanCollection normalizes a collection path
+// (like "LTO tapes/2023")
+func CleanCollection(collection string) string {
+	var parts []string
+	for _, part := range strings.Split(collection, CollectionSeparator) {
+		part = strings.TrimSpace(part)
+		if len(part) > 0 {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, CollectionSeparator)
+}
+
+// IsCollection returns true if node is a collection
+func IsCollection(n Node) bool {
+	return n.GetType() == FileTypeCollection
+}
+
+// GetName returns this collection last level
+func (n *CollectionNode) GetName() string {
+	return filepath.Base(n.Path)
+}
+
+// GetDirectChildren returns nothing, storages are held by the tree
+func (n *CollectionNode) GetDirectChildren() map[string]*FileNode {
+	return nil
+}
+
+// GetSortedDirectChildren returns nothing, storages are held by the tree
+func (n *CollectionNode) GetSortedDirectChildren() []*FileNode {
+	return nil
+}
+
+// GetPath returns the collection full path
+func (n *CollectionNode) GetPath() string {
+	return n.Path
+}
+
+// GetType returns the node type
+func (n *CollectionNode) GetType() FileType {
+	return FileTypeCollection
+}
+
+// GetMAccess returns the most recent indexing of its storages
+func (n *CollectionNode) GetMAccess() int64 {
+	return n.IndexedAt
+}
+
+// GetMode returns the collection mode
+func (n *CollectionNode) GetMode() string {
+	return "drwxr-xr-x" // 0755
+}
+
+// GetAttr returns the node attribute as string
+func (n *CollectionNode) GetAttr(rawSize bool, long bool) map[string]string {
+	attrs := make(map[string]string)
+	if !long {
+		return attrs
+	}
+	attrs["storages"] = fmt.Sprintf("%d", n.Storages)
+	attrs["size"] = sizeToString(n.Size, rawSize)
+	attrs["indexed"] = helpers.DateToString(n.IndexedAt)
+	return attrs
+}
+
+// GetSize returns the size of the storages in this collection
+func (n *CollectionNode) GetSize() uint64 {
+	return n.Size
+}
+
+// SetSize sets the node size field
+func (n *CollectionNode) SetSize(size uint64) {
+	n.Size = size
+}
+
+// Seen always true
+func (n *CollectionNode) Seen() bool {
+	return true
+}
+
+// AddChild unsupported
+func (n *CollectionNode) AddChild(*FileNode) {}
+
+// RemoveChild unsupported
+func (n *CollectionNode) RemoveChild(Node) {}
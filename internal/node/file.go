@@ -31,8 +31,25 @@ func (n *FileNode) GetMode() string {
 	return n.Mode
 }
 
+// SetChildrenLoader sets the function used to load
+// this node children on first access
+func (n *FileNode) SetChildrenLoader(loader ChildrenLoader) {
+	n.loader = loader
+}
+
+// load the children if not done yet
+func (n *FileNode) loadChildren() {
+	if n.loader == nil {
+		return
+	}
+	loader := n.loader
+	n.loader = nil
+	n.Children = loader()
+}
+
 // GetDirectChildren returns this node children
 func (n *FileNode) GetDirectChildren() map[string]*FileNode {
+	n.loadChildren()
 	children := make(map[string]*FileNode, len(n.Children))
 	for _, child := range n.Children {
 		children[child.GetName()] = child
@@ -42,6 +59,7 @@ func (n *FileNode) GetDirectChildren() map[string]*FileNode {
 
 // GetSortedDirectChildren returns children sorted by names
 func (n *FileNode) GetSortedDirectChildren() []*FileNode {
+	n.loadChildren()
 	sort.Slice(n.Children, func(i, j int) bool {
 		left := n.Children[i]
 		right := n.Children[j]
@@ -73,11 +91,13 @@ func (n *FileNode) GetPath() string {
 
 // AddChild adds a child to this node
 func (n *FileNode) AddChild(child *FileNode) {
+	n.loadChildren()
 	n.Children = append(n.Children, child)
 }
 
 // RemoveChild removes a child from this node
 func (n *FileNode) RemoveChild(removeMe Node) {
+	n.loadChildren()
 	var newChildrenSlice []*FileNode
 	for _, child := range n.Children {
 		if child.GetName() != removeMe.GetName() {
@@ -142,11 +162,59 @@ func (n *FileNode) GetAttr(rawSize bool, long bool) map[string]string {
 	}
 
 	// nb children
+	n.loadChildren()
 	attrs["children"] = fmt.Sprint(len(n.Children))
 
+	// user annotations
+	if len(n.Tags) > 0 {
+		tags := append([]string{}, n.Tags...)
+		sort.Strings(tags)
+		attrs["tags"] = strings.Join(tags, ",")
+	}
+	if len(n.Note) > 0 {
+		attrs["note"] = n.Note
+	}
+
+	// extracted metadata
+	for key, value := range n.Metadata {
+		attrs[MetadataPrefix+key] = value
+	}
+
 	return attrs
 }
 
+// Tag adds a tag to the node
+func (n *FileNode) Tag(tag string) {
+	n.Tags = helpers.UniqStrings(n.Tags, []string{tag})
+	sort.Strings(n.Tags)
+}
+
+// Untag removes a tag from the node
+func (n *FileNode) Untag(tag string) {
+	var slice []string
+	for _, t := range n.Tags {
+		if t != tag {
+			slice = append(slice, t)
+		}
+	}
+	n.Tags = slice
+}
+
+// SetNote sets the node note, an empty note removes it
+func (n *FileNode) SetNote(note string) {
+	n.Note = note
+}
+
+// KeepAnnotations copies the user tags and note of a previous
+// version of this node
+func (n *FileNode) KeepAnnotations(previous *FileNode) {
+	if previous == nil {
+		return
+	}
+	n.Tags = previous.Tags
+	n.Note = previous.Note
+}
+
 // IsExec is file executable
 func (n *FileNode) IsExec() bool {
 	return strings.Count(n.Mode, "x") == 3
@@ -186,6 +254,23 @@ func (n *FileNode) Seen() bool {
 	return n.seen
 }
 
+// MarkSeen marks this node and its children as seen
+func (n *FileNode) MarkSeen() {
+	n.seen = true
+	for _, child := range n.Children {
+		child.MarkSeen()
+	}
+}
+
+// Unchanged returns true if info matches what was indexed,
+// directory size is the size of their content and is not compared
+func (n *FileNode) Unchanged(info fs.FileInfo) bool {
+	if n.Maccess != info.ModTime().Unix() {
+		return false
+	}
+	return info.IsDir() || n.Size == uint64(info.Size())
+}
+
 // Update updates the node info
 func (n *FileNode) Update(info fs.FileInfo) {
 	n.Name = info.Name()
@@ -204,6 +289,7 @@ func NewArchivedFileNode(storageID int, path string, info fs.FileInfo, nameInsid
 	}
 	node.Type = FileTypeArchived
 	node.Name = nameInsideArchive
+	node.ID = DeriveFileID(storageID, node.GetPath())
 	node.seen = true
 	return node
 }
@@ -224,6 +310,13 @@ func NewFileNode(storageID int, path string, info fs.FileInfo) *FileNode {
 	node.Update(info)
 	node.seen = true
 	node.RelPath = path
+	node.ID = DeriveFileID(storageID, path)
 
 	return &node
 }
+
+// DeriveFileID derives a stable id from the storage
+// and the path of the node in that storage
+func DeriveFileID(storageID int, path string) string {
+	return fmt.Sprintf("%016x", helpers.HashString64(fmt.Sprintf("%d:%s", storageID, path)))
+}
@@ -16,38 +16,52 @@ const (
 	FileTypeArchive = "archive"
 	// FileTypeArchived an archived file
 	FileTypeArchived = "archived"
+	// FileTypeCollection a collection of storages
+	FileTypeCollection = "collection"
+	// MetadataPrefix prefixes the extracted metadata keys
+	// in the attributes and the queries
+	MetadataPrefix = "meta."
 )
 
 // FileNode a file node
 type FileNode struct {
-	ID        string      `json:"id" toml:"id"`
-	Name      string      `json:"name" toml:"name"`
-	RelPath   string      `json:"relpath" toml:"relpath"` // to the storage node
-	Checksum  string      `json:"md5" toml:"md5"`
-	Type      FileType    `json:"filetype" toml:"filetype"`
-	Size      uint64      `json:"size" toml:"size"`
-	Maccess   int64       `json:"maccess" toml:"maccess"`
-	Children  []*FileNode `json:"children" toml:"children"`
-	IndexedAt int64       `json:"ts" toml:"ts"`
-	StorageID int         `json:"storage_id" toml:"storage_id"`
-	Mode      string      `json:"mode" toml:"mode"`
-	Mime      string      `json:"mime" toml:"mime"`
-	Extra     string      `json:"extra" toml:"extra"` // comma separated list of `<key>:<value>`
-	seen      bool        `json:"-" toml:"-"`         // seen tag when updating a storage
+	ID        string            `json:"id" toml:"id"`
+	Name      string            `json:"name" toml:"name"`
+	RelPath   string            `json:"relpath" toml:"relpath"`             // to the storage node
+	Checksum  string            `json:"checksum" toml:"checksum"`           // <algo>:<digest>
+	MD5       string            `json:"md5,omitempty" toml:"md5,omitempty"` // deprecated, see Checksum
+	Type      FileType          `json:"filetype" toml:"filetype"`
+	Size      uint64            `json:"size" toml:"size"`
+	Maccess   int64             `json:"maccess" toml:"maccess"`
+	Children  []*FileNode       `json:"children" toml:"children"`
+	IndexedAt int64             `json:"ts" toml:"ts"`
+	StorageID int               `json:"storage_id" toml:"storage_id"`
+	Mode      string            `json:"mode" toml:"mode"`
+	Mime      string            `json:"mime" toml:"mime"`
+	Extra     string            `json:"extra" toml:"extra"` // comma separated list of `<key>:<value>`
+	Tags      []string          `json:"tags,omitempty" toml:"tags,omitempty"`
+	Note      string            `json:"note,omitempty" toml:"note,omitempty"`
+	Metadata  map[string]string `json:"metadata,omitempty" toml:"metadata,omitempty"` // extracted from the content
+	seen      bool              `json:"-" toml:"-"`                                   // seen tag when updating a storage
+	loader    ChildrenLoader    `json:"-" toml:"-"`                                   // loads children on demand
 }
 
 // StorageNode a storage node
 type StorageNode struct {
-	ID         int         `json:"id" toml:"id"`
-	Name       string      `json:"name" toml:"name"`
-	Path       string      `json:"path" toml:"path"`
-	Size       uint64      `json:"size" toml:"size"`
-	Free       uint64      `json:"free" toml:"free"`
-	Total      uint64      `json:"total" toml:"total"`
-	IndexedAt  int64       `json:"ts" toml:"ts"`
-	Type       FileType    `json:"type" toml:"type"`
-	Tags       []string    `json:"tags" toml:"tags"`
-	Meta       string      `json:"meta" toml:"meta"`
-	TotalFiles uint64      `json:"nb_files" toml:"nb_files"`
-	Children   []*FileNode `json:"children" toml:"children"`
+	ID           int            `json:"id" toml:"id"`
+	Name         string         `json:"name" toml:"name"`
+	Path         string         `json:"path" toml:"path"`
+	Size         uint64         `json:"size" toml:"size"`
+	Free         uint64         `json:"free" toml:"free"`
+	Total        uint64         `json:"total" toml:"total"`
+	IndexedAt    int64          `json:"ts" toml:"ts"`
+	Type         FileType       `json:"type" toml:"type"`
+	Tags         []string       `json:"tags" toml:"tags"`
+	Meta         string         `json:"meta" toml:"meta"`
+	TotalFiles   uint64         `json:"nb_files" toml:"nb_files"`
+	ChecksumAlgo string         `json:"checksum_algo" toml:"checksum_algo"`
+	Collection   string         `json:"collection,omitempty" toml:"collection,omitempty"` // like "LTO tapes/2023"
+	Children     []*FileNode    `json:"children" toml:"children"`
+	dirty        bool           `json:"-" toml:"-"` // children changed since last load
+	loader       ChildrenLoader `json:"-" toml:"-"` // loads children on demand
 }
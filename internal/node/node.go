@@ -14,6 +14,10 @@ const (
 // FileType node file type
 type FileType string
 
+// ChildrenLoader returns the children of a node
+// when these are loaded on demand
+type ChildrenLoader func() []*FileNode
+
 // Node generic node interface
 type Node interface {
 	GetName() string
@@ -39,7 +43,7 @@ func ShouldDescendForRecSize(n Node) bool {
 
 // MayHaveChildren returns true if the node may have children
 func MayHaveChildren(n Node) bool {
-	return n.GetType() == FileTypeDir || n.GetType() == FileTypeStorage || n.GetType() == FileTypeArchive
+	return n.GetType() == FileTypeDir || n.GetType() == FileTypeStorage || n.GetType() == FileTypeArchive || n.GetType() == FileTypeCollection
 }
 
 // IsDir returns true if node is a directory
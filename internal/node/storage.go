@@ -40,9 +40,29 @@ func (n *StorageNode) IsDir() bool {
 	return true
 }
 
+// SetChildrenLoader sets the function used to load
+// this storage children on first access
+func (n *StorageNode) SetChildrenLoader(loader ChildrenLoader) {
+	n.loader = loader
+}
+
+// load the children if not done yet
+func (n *StorageNode) loadChildren() {
+	if n.loader == nil {
+		return
+	}
+	loader := n.loader
+	n.loader = nil
+	n.Children = loader()
+}
+
 // GetDirectChildren returns this node children
 func (n *StorageNode) GetDirectChildren() map[string]*FileNode {
-	if n == nil || n.Children == nil {
+	if n == nil {
+		return nil
+	}
+	n.loadChildren()
+	if n.Children == nil {
 		return nil
 	}
 	children := make(map[string]*FileNode, len(n.Children))
@@ -54,6 +74,7 @@ func (n *StorageNode) GetDirectChildren() map[string]*FileNode {
 
 // GetSortedDirectChildren returns children sorted by names
 func (n *StorageNode) GetSortedDirectChildren() []*FileNode {
+	n.loadChildren()
 	sort.Slice(n.Children, func(i, j int) bool {
 		left := n.Children[i]
 		right := n.Children[j]
@@ -82,6 +103,7 @@ func (n *StorageNode) GetPath() string {
 
 // AddChild adds a new child to this node
 func (n *StorageNode) AddChild(child *FileNode) {
+	n.loadChildren()
 	n.Children = append(n.Children, child)
 }
 
@@ -90,6 +112,16 @@ func (n *StorageNode) Seen() bool {
 	return true
 }
 
+// SetDirty flags the storage children as changed
+func (n *StorageNode) SetDirty(dirty bool) {
+	n.dirty = dirty
+}
+
+// IsDirty returns true if the storage children changed
+func (n *StorageNode) IsDirty() bool {
+	return n.dirty
+}
+
 // RecursiveFillSize recusively fills each node total size
 func (n *StorageNode) RecursiveFillSize() {
 	var size uint64
@@ -108,6 +140,7 @@ func (n *StorageNode) RecursiveFillSize() {
 
 // RemoveChild removes a child from this node
 func (n *StorageNode) RemoveChild(removeMe Node) {
+	n.loadChildren()
 	var newChildrenSlice []*FileNode
 	for _, child := range n.Children {
 		if child.GetName() != removeMe.GetName() {
@@ -149,9 +182,13 @@ func (n *StorageNode) GetAttr(rawSize bool, long bool) map[string]string {
 	attrs["indexed"] = helpers.DateToString(n.IndexedAt)
 
 	attrs["meta"] = n.Meta
+	attrs["checksum"] = n.ChecksumAlgo
 	tags := n.Tags
 	sort.Strings(tags)
 	attrs["tags"] = strings.Join(tags, ",")
+	if len(n.Collection) > 0 {
+		attrs["collection"] = n.Collection
+	}
 
 	return attrs
 }
@@ -198,10 +235,9 @@ func (n *StorageNode) UpdateStorage(fsPath string, path string, meta string, tag
 	n.IndexedAt = time.Now().Unix()
 }
 
-// DeriveStorageID derive id from storage name
+// DeriveStorageID derive a stable id from storage name
 func DeriveStorageID(name string) int {
-	now := time.Now().Format("2006-01-02 15:04:05")
-	return helpers.HashString(name + now)
+	return helpers.HashString(name)
 }
 
 // NewStorageNode creates a new storage node
@@ -0,0 +1,120 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package query
+
+import (
+	"fmt"
+	"strings"
+)
+
+type tokenType int
+
+const (
+	tokenEOF tokenType = iota
+	tokenWord
+	tokenString // quoted
+	tokenOp
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenLParen
+	tokenRParen
+)
+
+const (
+	opEq       = "="
+	opNe       = "!="
+	opLt       = "<"
+	opLe       = "<="
+	opGt       = ">"
+	opGe       = ">="
+	opMatch    = "~"
+	opNotMatch = "!~"
+)
+
+// operators, longest first
+var operators = []string{opNe, opLe, opGe, opNotMatch, "==", opEq, opLt, opGt, opMatch}
+
+type token struct {
+	typ tokenType
+	val string
+	pos int
+}
+
+func (t *token) String() string {
+	if t.typ == tokenEOF {
+		return "end of query"
+	}
+	return fmt.Sprintf("\"%s\" at position %d", t.val, t.pos)
+}
+
+func isSpecial(c byte) bool {
+	return strings.IndexByte(" \t\n()=!<>~\"'", c) >= 0
+}
+
+// tokenize splits the query in tokens
+func tokenize(s string) ([]*token, error) {
+	var tokens []*token
+	i := 0
+	for i < len(s) {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n':
+			i++
+		case c == '(':
+			tokens = append(tokens, &token{typ: tokenLParen, val: "(", pos: i})
+			i++
+		case c == ')':
+			tokens = append(tokens, &token{typ: tokenRParen, val: ")", pos: i})
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at position %d", i)
+			}
+			tokens = append(tokens, &token{typ: tokenString, val: s[i+1 : i+1+end], pos: i})
+			i += end + 2
+		default:
+			op := ""
+			for _, o := range operators {
+				if strings.HasPrefix(s[i:], o) {
+					op = o
+					break
+				}
+			}
+			if len(op) > 0 {
+				tokens = append(tokens, &token{typ: tokenOp, val: op, pos: i})
+				if op == "==" {
+					tokens[len(tokens)-1].val = opEq
+				}
+				i += len(op)
+				continue
+			}
+			if c == '!' {
+				tokens = append(tokens, &token{typ: tokenNot, val: "!", pos: i})
+				i++
+				continue
+			}
+			start := i
+			for i < len(s) && !isSpecial(s[i]) {
+				i++
+			}
+			word := s[start:i]
+			typ := tokenWord
+			switch strings.ToLower(word) {
+			case "and", "&&":
+				typ = tokenAnd
+			case "or", "||":
+				typ = tokenOr
+			case "not":
+				typ = tokenNot
+			}
+			tokens = append(tokens, &token{typ: typ, val: word, pos: start})
+		}
+	}
+	tokens = append(tokens, &token{typ: tokenEOF, pos: len(s)})
+	return tokens, nil
+}
@@ -0,0 +1,132 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package query
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/deadc0de6/gocatcli/internal/helpers"
+)
+
+// parser a recursive descent parser
+//
+//	expr    := and ("or" and)*
+//	and     := unary ("and" unary)*
+//	unary   := "not" unary | primary
+//	primary := "(" expr ")" | field op value | pattern
+type parser struct {
+	tokens []*token
+	pos    int
+}
+
+func (p *parser) peek() *token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() *token {
+	t := p.tokens[p.pos]
+	if t.typ != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) parseOr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().typ == tokenOr {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().typ == tokenAnd {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andExpr{left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (expr, error) {
+	if p.peek().typ == tokenNot {
+		p.next()
+		sub, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notExpr{sub: sub}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	t := p.next()
+	switch t.typ {
+	case tokenLParen:
+		sub, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		closing := p.next()
+		if closing.typ != tokenRParen {
+			return nil, fmt.Errorf("expecting \")\", got %s", closing)
+		}
+		return sub, nil
+	case tokenWord, tokenString:
+		if p.peek().typ == tokenOp {
+			if t.typ != tokenWord {
+				return nil, fmt.Errorf("expecting a field, got %s", t)
+			}
+			op := p.next()
+			value := p.next()
+			if value.typ != tokenWord && value.typ != tokenString {
+				return nil, fmt.Errorf("expecting a value after %s, got %s", op, value)
+			}
+			return newCmpExpr(t.val, op.val, value.val)
+		}
+		// a name pattern like the ones of find
+		re, err := regexp.Compile(helpers.PatchPattern(t.val))
+		if err != nil {
+			return nil, err
+		}
+		return &nameExpr{re: re}, nil
+	}
+	return nil, fmt.Errorf("unexpected %s", t)
+}
+
+// parse parses the query
+func parse(s string) (expr, error) {
+	tokens, err := tokenize(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	e, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.typ != tokenEOF {
+		return nil, fmt.Errorf("unexpected %s", t)
+	}
+	return e, nil
+}
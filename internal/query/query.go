@@ -0,0 +1,428 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deadc0de6/gocatcli/internal/helpers"
+	"github.com/deadc0de6/gocatcli/internal/node"
+)
+
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindSize
+	kindDate
+	kindList
+	kindMeta // "meta.<key>" extracted metadata
+)
+
+var (
+	// fields that can be queried
+	fields = map[string]fieldKind{
+		"name":         kindString,
+		"path":         kindString,
+		"type":         kindString,
+		"mode":         kindString,
+		"mime":         kindString,
+		"checksum":     kindString,
+		"size":         kindSize,
+		"maccess":      kindDate,
+		"indexed":      kindDate,
+		"tag":          kindList,
+		"note":         kindString,
+		"storage":      kindString,
+		"storage.name": kindString,
+		"storage.meta": kindString,
+		"storage.tag":  kindList,
+	}
+
+	// date layouts with the precision they imply
+	dateLayouts = []struct {
+		layout string
+		years  int
+		months int
+		days   int
+		dur    time.Duration
+	}{
+		{"2006", 1, 0, 0, 0},
+		{"2006-01", 0, 1, 0, 0},
+		{"2006-01-02", 0, 0, 1, 0},
+		{"2006-01-02T15:04", 0, 0, 0, time.Minute},
+		{"2006-01-02 15:04", 0, 0, 0, time.Minute},
+		{"2006-01-02T15:04:05", 0, 0, 0, time.Second},
+		{"2006-01-02 15:04:05", 0, 0, 0, time.Second},
+	}
+)
+
+// Query a parsed query
+type Query struct {
+	src  string
+	root expr
+}
+
+// expr a node of the predicate tree
+type expr interface {
+	eval(n node.Node, storage *node.StorageNode) bool
+}
+
+type andExpr struct {
+	left  expr
+	right expr
+}
+
+func (e *andExpr) eval(n node.Node, storage *node.StorageNode) bool {
+	return e.left.eval(n, storage) && e.right.eval(n, storage)
+}
+
+type orExpr struct {
+	left  expr
+	right expr
+}
+
+func (e *orExpr) eval(n node.Node, storage *node.StorageNode) bool {
+	return e.left.eval(n, storage) || e.right.eval(n, storage)
+}
+
+type notExpr struct {
+	sub expr
+}
+
+func (e *notExpr) eval(n node.Node, storage *node.StorageNode) bool {
+	return !e.sub.eval(n, storage)
+}
+
+// nameExpr matches the name like find patterns
+type nameExpr struct {
+	re *regexp.Regexp
+}
+
+func (e *nameExpr) eval(n node.Node, _ *node.StorageNode) bool {
+	return e.re.MatchString(n.GetName())
+}
+
+// cmpExpr compares a field with a value
+type cmpExpr struct {
+	field string
+	kind  fieldKind
+	op    string
+	value string
+	re    *regexp.Regexp
+	// numeric values are ranges [low, high)
+	// to honor the precision of the literal
+	low  int64
+	high int64
+	// the numeric value of metadata comparisons
+	num float64
+}
+
+func (e *cmpExpr) eval(n node.Node, storage *node.StorageNode) bool {
+	switch e.kind {
+	case kindSize, kindDate:
+		return e.evalNum(numValue(e.field, n))
+	case kindList:
+		return e.evalList(listValue(e.field, n, storage))
+	case kindMeta:
+		return e.evalMeta(metaValue(e.field, n))
+	}
+	return e.evalString(stringValue(e.field, n, storage))
+}
+
+func (e *cmpExpr) evalNum(v int64) bool {
+	switch e.op {
+	case opEq:
+		return v >= e.low && v < e.high
+	case opNe:
+		return v < e.low || v >= e.high
+	case opLt:
+		return v < e.low
+	case opLe:
+		return v < e.high
+	case opGt:
+		return v >= e.high
+	case opGe:
+		return v >= e.low
+	}
+	return false
+}
+
+// evalMeta compares the metadata as numbers for
+// the ordering operators and as strings otherwise
+func (e *cmpExpr) evalMeta(v string) bool {
+	switch e.op {
+	case opLt, opLe, opGt, opGe:
+	default:
+		return e.evalString(v)
+	}
+	num, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return false
+	}
+	switch e.op {
+	case opLt:
+		return num < e.num
+	case opLe:
+		return num <= e.num
+	case opGt:
+		return num > e.num
+	}
+	return num >= e.num
+}
+
+func (e *cmpExpr) evalString(v string) bool {
+	switch e.op {
+	case opEq:
+		return e.equals(v)
+	case opNe:
+		return !e.equals(v)
+	case opMatch:
+		return e.re.MatchString(v)
+	case opNotMatch:
+		return !e.re.MatchString(v)
+	}
+	return false
+}
+
+func (e *cmpExpr) equals(v string) bool {
+	if e.field == "checksum" && !strings.Contains(e.value, ":") {
+		// compare the digest only
+		_, v = helpers.SplitChecksum(v)
+	}
+	return v == e.value
+}
+
+func (e *cmpExpr) evalList(values []string) bool {
+	var found bool
+	for _, v := range values {
+		if e.op == opEq || e.op == opNe {
+			found = v == e.value
+		} else {
+			found = e.re.MatchString(v)
+		}
+		if found {
+			break
+		}
+	}
+	if e.op == opNe || e.op == opNotMatch {
+		return !found
+	}
+	return found
+}
+
+func newCmpExpr(field string, op string, value string) (expr, error) {
+	kind, ok := fields[strings.ToLower(field)]
+	if !ok && strings.HasPrefix(strings.ToLower(field), node.MetadataPrefix) && len(field) > len(node.MetadataPrefix) {
+		kind, ok = kindMeta, true
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown field \"%s\" (%s)", field, strings.Join(Fields(), ","))
+	}
+	e := &cmpExpr{
+		field: strings.ToLower(field),
+		kind:  kind,
+		op:    op,
+		value: value,
+	}
+
+	var err error
+	switch kind {
+	case kindSize:
+		e.low, e.high, err = parseSize(value)
+	case kindDate:
+		e.low, e.high, err = parseDate(value)
+	case kindMeta:
+		switch op {
+		case opEq, opNe:
+		case opMatch, opNotMatch:
+			e.re, err = regexp.Compile(value)
+		default:
+			e.num, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				err = fmt.Errorf("operator \"%s\" expects a number for \"%s\"", op, field)
+			}
+		}
+	default:
+		switch op {
+		case opEq, opNe:
+		case opMatch, opNotMatch:
+			e.re, err = regexp.Compile(value)
+		default:
+			err = fmt.Errorf("operator \"%s\" not supported for \"%s\"", op, field)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if (kind == kindSize || kind == kindDate) && (op == opMatch || op == opNotMatch) {
+		return nil, fmt.Errorf("operator \"%s\" not supported for \"%s\"", op, field)
+	}
+	return e, nil
+}
+
+// parseSize parses sizes like "1024", "10K", "1.5GB"
+func parseSize(value string) (int64, int64, error) {
+	size, err := helpers.HumanToSize(value)
+	if err != nil {
+		return 0, 0, err
+	}
+	return int64(size), int64(size) + 1, nil
+}
+
+// parseDate parses dates like "2020", "2020-01-01" or "2020-01-01T10:00",
+// the range covers the whole period of the date (the year, the day, etc)
+func parseDate(value string) (int64, int64, error) {
+	for _, l := range dateLayouts {
+		t, err := time.ParseInLocation(l.layout, value, time.Local)
+		if err != nil {
+			continue
+		}
+		end := t.AddDate(l.years, l.months, l.days).Add(l.dur)
+		return t.Unix(), end.Unix(), nil
+	}
+	return 0, 0, fmt.Errorf("invalid date \"%s\" (expecting YYYY-MM-DD[THH:MM[:SS]])", value)
+}
+
+func numValue(field string, n node.Node) int64 {
+	switch field {
+	case "size":
+		return int64(n.GetSize())
+	case "maccess":
+		return n.GetMAccess()
+	case "indexed":
+		switch v := n.(type) {
+		case *node.FileNode:
+			return v.IndexedAt
+		case *node.StorageNode:
+			return v.IndexedAt
+		}
+	}
+	return 0
+}
+
+func stringValue(field string, n node.Node, storage *node.StorageNode) string {
+	switch field {
+	case "name":
+		return n.GetName()
+	case "path":
+		return n.GetPath()
+	case "type":
+		return string(n.GetType())
+	case "mode":
+		return n.GetMode()
+	case "mime":
+		if f, ok := n.(*node.FileNode); ok {
+			return f.Mime
+		}
+	case "checksum":
+		if f, ok := n.(*node.FileNode); ok {
+			return f.Checksum
+		}
+	case "note":
+		if f, ok := n.(*node.FileNode); ok {
+			return f.Note
+		}
+	case "storage", "storage.name":
+		if storage != nil {
+			return storage.GetName()
+		}
+	case "storage.meta":
+		if storage != nil {
+			return storage.Meta
+		}
+	}
+	return ""
+}
+
+// metaValue returns the extracted metadata of a file,
+// empty if not found
+func metaValue(field string, n node.Node) string {
+	f, ok := n.(*node.FileNode)
+	if !ok {
+		return ""
+	}
+	return f.Metadata[strings.TrimPrefix(field, node.MetadataPrefix)]
+}
+
+func listValue(field string, n node.Node, storage *node.StorageNode) []string {
+	switch field {
+	case "tag":
+		switch v := n.(type) {
+		case *node.StorageNode:
+			return v.Tags
+		case *node.FileNode:
+			return v.Tags
+		}
+	case "storage.tag":
+		if storage != nil {
+			return storage.Tags
+		}
+	}
+	return nil
+}
+
+// Match returns true if the node matches the query,
+// storage is the storage the node belongs to
+func (q *Query) Match(n node.Node, storage *node.StorageNode) bool {
+	return q.root.eval(n, storage)
+}
+
+// String returns the query
+func (q *Query) String() string {
+	return q.src
+}
+
+// Fields returns the fields that can be queried
+func Fields() []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return append(keys, node.MetadataPrefix+"<key>")
+}
+
+// IsQuery returns true if s is a query comparing
+// some fields and not only a name pattern
+func IsQuery(s string) bool {
+	root, err := parse(s)
+	if err != nil {
+		return false
+	}
+	return hasComparison(root)
+}
+
+func hasComparison(e expr) bool {
+	switch v := e.(type) {
+	case *andExpr:
+		return hasComparison(v.left) || hasComparison(v.right)
+	case *orExpr:
+		return hasComparison(v.left) || hasComparison(v.right)
+	case *notExpr:
+		return hasComparison(v.sub)
+	case *cmpExpr:
+		return true
+	}
+	return false
+}
+
+// Parse parses a query like "size>1G and (mime~video/ or name~mkv$)"
+func Parse(s string) (*Query, error) {
+	root, err := parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("bad query: %v", err)
+	}
+	return &Query{
+		src:  s,
+		root: root,
+	}, nil
+}
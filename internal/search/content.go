@@ -0,0 +1,273 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package search
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/deadc0de6/gocatcli/internal/log"
+	"github.com/deadc0de6/gocatcli/internal/node"
+	"github.com/deadc0de6/gocatcli/internal/tree"
+)
+
+const (
+	// ContentSuffix of the full-text index file beside the catalog
+	ContentSuffix = ".fts"
+
+	contentVersion = 1
+	// words shorter or longer are not indexed
+	minWordLen = 2
+	maxWordLen = 64
+)
+
+// Document the text of a file
+type Document struct {
+	ID      string // of the file node
+	Storage int    // id of the storage of the file
+	Text    string
+}
+
+// Content a full-text index of the content of the files,
+// documents are found by the words they contain and the text
+// is kept to be searched when the storages are offline
+type Content struct {
+	Version   int
+	Documents []*Document         // sorted by id
+	Words     map[string][]uint32 // word to sorted documents indices
+	docs      map[string]*Document
+	lock      sync.Mutex
+}
+
+// ContentPath returns the path of the full-text index of a catalog
+func ContentPath(catalogPath string) string {
+	return catalogPath + ContentSuffix
+}
+
+// ContentExists returns true if the catalog has a full-text index
+func ContentExists(catalogPath string) bool {
+	_, err := os.Stat(ContentPath(catalogPath))
+	return err == nil
+}
+
+// NewContent creates an empty full-text index
+func NewContent() *Content {
+	return &Content{
+		Version: contentVersion,
+		Words:   make(map[string][]uint32),
+		docs:    make(map[string]*Document),
+	}
+}
+
+// Words returns the unique lowercase words of text
+func Words(text string) []string {
+	seen := make(map[string]bool)
+	var words []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len(word) < minWordLen || len(word) > maxWordLen || seen[word] {
+			continue
+		}
+		seen[word] = true
+		words = append(words, word)
+	}
+	return words
+}
+
+// Has returns true if the node has a document
+func (c *Content) Has(id string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	_, ok := c.docs[id]
+	return ok
+}
+
+// Set sets the text of a node of the storage,
+// an empty text removes its document
+func (c *Content) Set(storageID int, id string, text string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(text) < 1 {
+		delete(c.docs, id)
+		return
+	}
+	c.docs[id] = &Document{
+		ID:      id,
+		Storage: storageID,
+		Text:    text,
+	}
+}
+
+// Prune drops the documents of the files not found under
+// storage anymore and the ones of the storages not in the tree
+func (c *Content) Prune(t *tree.Tree, storage *node.StorageNode) {
+	storages := make(map[int]bool)
+	for _, sto := range t.GetStorages() {
+		storages[sto.ID] = true
+	}
+	ids := make(map[string]bool)
+	t.ProcessChildren(storage, true, func(n node.Node, _ int, _ node.Node) bool {
+		if f, ok := n.(*node.FileNode); ok {
+			ids[f.ID] = true
+		}
+		return true
+	}, -1)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for id, doc := range c.docs {
+		if !storages[doc.Storage] || (doc.Storage == storage.ID && !ids[id]) {
+			delete(c.docs, id)
+		}
+	}
+}
+
+// Len returns the number of documents
+func (c *Content) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return len(c.docs)
+}
+
+// build fills the documents and the words from the set documents
+func (c *Content) build() {
+	c.Documents = make([]*Document, 0, len(c.docs))
+	for _, doc := range c.docs {
+		c.Documents = append(c.Documents, doc)
+	}
+	sort.Slice(c.Documents, func(i, j int) bool {
+		return c.Documents[i].ID < c.Documents[j].ID
+	})
+	c.Words = make(map[string][]uint32)
+	for pos, doc := range c.Documents {
+		for _, word := range Words(doc.Text) {
+			c.Words[word] = append(c.Words[word], uint32(pos))
+		}
+	}
+	log.Debugf("full-text index built with %d documents and %d words", len(c.Documents), len(c.Words))
+}
+
+// Save writes the full-text index for the catalog
+func (c *Content) Save(catalogPath string) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.build()
+
+	path := ContentPath(catalogPath)
+	fd, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := fd.Name()
+	zw := gzip.NewWriter(fd)
+	err = gob.NewEncoder(zw).Encode(c)
+	if err == nil {
+		err = zw.Close()
+	}
+	cerr := fd.Close()
+	if err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	log.Debugf("full-text index saved to \"%s\"", path)
+	return nil
+}
+
+// LoadContent reads the full-text index of the catalog
+func LoadContent(catalogPath string) (*Content, error) {
+	fd, err := os.Open(ContentPath(catalogPath))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := fd.Close()
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+	zr, err := gzip.NewReader(fd)
+	if err != nil {
+		return nil, err
+	}
+	var c Content
+	err = gob.NewDecoder(zr).Decode(&c)
+	if err != nil {
+		return nil, err
+	}
+	if c.Version != contentVersion {
+		return nil, fmt.Errorf("unsupported full-text index version %d", c.Version)
+	}
+	c.docs = make(map[string]*Document)
+	for _, doc := range c.Documents {
+		c.docs[doc.ID] = doc
+	}
+	return &c, nil
+}
+
+// Candidates returns the documents that may contain all the
+// words of the text, words may be parts of the indexed words.
+// All the documents are returned if the text has no word
+func (c *Content) Candidates(text string) []*Document {
+	words := Words(text)
+	if len(words) < 1 {
+		return c.Documents
+	}
+
+	// the indexed words are scanned once
+	postings := make([][]uint32, len(words))
+	for indexed, positions := range c.Words {
+		for i, word := range words {
+			if strings.Contains(indexed, word) {
+				postings[i] = union(postings[i], positions)
+			}
+		}
+	}
+
+	result := postings[0]
+	for _, positions := range postings[1:] {
+		result = intersect(result, positions)
+	}
+	docs := make([]*Document, len(result))
+	for i, pos := range result {
+		docs[i] = c.Documents[pos]
+	}
+	return docs
+}
+
+// union merges two sorted lists
+func union(left []uint32, right []uint32) []uint32 {
+	out := make([]uint32, 0, len(left)+len(right))
+	i, j := 0, 0
+	for i < len(left) || j < len(right) {
+		switch {
+		case j >= len(right) || (i < len(left) && left[i] < right[j]):
+			out = append(out, left[i])
+			i++
+		case i >= len(left) || right[j] < left[i]:
+			out = append(out, right[j])
+			j++
+		default:
+			out = append(out, left[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
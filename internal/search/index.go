@@ -0,0 +1,327 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package search
+
+import (
+	"compress/gzip"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/deadc0de6/gocatcli/internal/log"
+	"github.com/deadc0de6/gocatcli/internal/node"
+	"github.com/deadc0de6/gocatcli/internal/tree"
+)
+
+const (
+	// Suffix of the search index file beside the catalog
+	Suffix = ".idx"
+
+	indexVersion = 1
+	noParent     = -1
+)
+
+// Entry an entry of the catalog
+type Entry struct {
+	Parent  int32 // index of the parent entry or noParent
+	Storage int32 // index of the storage
+	Name    string
+}
+
+// Index a trigram index of the entries names
+type Index struct {
+	Version     int
+	Fingerprint string // of the catalog file the index was built for
+	Storages    []string
+	Entries     []Entry
+	Trigrams    map[string][]uint32 // trigram to sorted entries indices
+	resolved    map[int32]node.Node
+	paths       []string         // relative path of the entries
+	byStorage   map[string][]int // entries indices by storage name
+}
+
+// Path returns the path of the search index of a catalog
+func Path(catalogPath string) string {
+	return catalogPath + Suffix
+}
+
+// Exists returns true if the catalog has a search index
+func Exists(catalogPath string) bool {
+	_, err := os.Stat(Path(catalogPath))
+	return err == nil
+}
+
+// Fingerprint identifies a revision of the catalog file
+func Fingerprint(catalogPath string) (string, error) {
+	info, err := os.Stat(catalogPath)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d-%d", info.Size(), info.ModTime().UnixNano()), nil
+}
+
+// trigrams returns the unique trigrams of s
+func trigrams(s string) []string {
+	if len(s) < 3 {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var out []string
+	for i := 0; i+3 <= len(s); i++ {
+		tri := s[i : i+3]
+		if seen[tri] {
+			continue
+		}
+		seen[tri] = true
+		out = append(out, tri)
+	}
+	return out
+}
+
+func (idx *Index) add(storage int32, parent int32, n node.Node) {
+	pos := uint32(len(idx.Entries))
+	idx.Entries = append(idx.Entries, Entry{
+		Parent:  parent,
+		Storage: storage,
+		Name:    n.GetName(),
+	})
+	for _, tri := range trigrams(n.GetName()) {
+		idx.Trigrams[tri] = append(idx.Trigrams[tri], pos)
+	}
+	for _, child := range n.GetSortedDirectChildren() {
+		idx.add(storage, int32(pos), child)
+	}
+}
+
+// Build indexes the entries of the tree, in the order
+// they are processed when walking the tree
+func Build(t *tree.Tree) *Index {
+	idx := &Index{
+		Version:  indexVersion,
+		Trigrams: make(map[string][]uint32),
+	}
+	for i, storage := range t.GetStorages() {
+		idx.Storages = append(idx.Storages, storage.GetName())
+		for _, child := range storage.GetSortedDirectChildren() {
+			idx.add(int32(i), noParent, child)
+		}
+	}
+	log.Debugf("search index built with %d entries and %d trigrams", len(idx.Entries), len(idx.Trigrams))
+	return idx
+}
+
+// Save writes the index for the catalog
+func (idx *Index) Save(catalogPath string) error {
+	var err error
+	idx.Fingerprint, err = Fingerprint(catalogPath)
+	if err != nil {
+		return err
+	}
+
+	path := Path(catalogPath)
+	fd, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := fd.Name()
+	zw := gzip.NewWriter(fd)
+	err = gob.NewEncoder(zw).Encode(idx)
+	if err == nil {
+		err = zw.Close()
+	}
+	cerr := fd.Close()
+	if err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	log.Debugf("search index saved to \"%s\"", path)
+	return nil
+}
+
+// Load reads the index of the catalog, an error
+// is returned if it does not match the catalog anymore
+func Load(catalogPath string) (*Index, error) {
+	fd, err := os.Open(Path(catalogPath))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := fd.Close()
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+	zr, err := gzip.NewReader(fd)
+	if err != nil {
+		return nil, err
+	}
+	var idx Index
+	err = gob.NewDecoder(zr).Decode(&idx)
+	if err != nil {
+		return nil, err
+	}
+	if idx.Version != indexVersion {
+		return nil, fmt.Errorf("unsupported search index version %d", idx.Version)
+	}
+	fp, err := Fingerprint(catalogPath)
+	if err != nil {
+		return nil, err
+	}
+	if fp != idx.Fingerprint {
+		return nil, fmt.Errorf("search index is stale")
+	}
+	return &idx, nil
+}
+
+// Literals returns the strings a name must contain to match
+// a find pattern (see helpers.PatchPattern). It returns false
+// if these cannot be determined (regex syntax)
+func Literals(pattern string) ([]string, bool) {
+	if strings.ContainsAny(pattern, "|?+{}()[]\\^$") {
+		return nil, false
+	}
+	var literals []string
+	for _, lit := range strings.Split(pattern, "*") {
+		if len(lit) > 0 {
+			literals = append(literals, lit)
+		}
+	}
+	return literals, true
+}
+
+// Candidates returns the sorted indices of the entries whose name
+// contains all the literals, false if the index cannot help
+// (no literal of at least 3 characters)
+func (idx *Index) Candidates(literals []string) ([]int, bool) {
+	var result []uint32
+	var used bool
+	for _, lit := range literals {
+		for _, tri := range trigrams(lit) {
+			postings := idx.Trigrams[tri]
+			if !used {
+				result = postings
+				used = true
+			} else {
+				result = intersect(result, postings)
+			}
+			if len(result) < 1 {
+				return nil, true
+			}
+		}
+	}
+	if !used {
+		return nil, false
+	}
+	out := make([]int, len(result))
+	for i, pos := range result {
+		out[i] = int(pos)
+	}
+	return out, true
+}
+
+// intersect two sorted lists
+func intersect(left []uint32, right []uint32) []uint32 {
+	var out []uint32
+	i, j := 0, 0
+	for i < len(left) && j < len(right) {
+		switch {
+		case left[i] < right[j]:
+			i++
+		case left[i] > right[j]:
+			j++
+		default:
+			out = append(out, left[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+// Len returns the number of entries
+func (idx *Index) Len() int {
+	return len(idx.Entries)
+}
+
+// StorageName returns the storage name of the entry
+func (idx *Index) StorageName(pos int) string {
+	return idx.Storages[idx.Entries[pos].Storage]
+}
+
+// RelPath returns the path of the entry in its storage
+func (idx *Index) RelPath(pos int) string {
+	if idx.paths == nil {
+		idx.buildPaths()
+	}
+	return idx.paths[pos]
+}
+
+// buildPaths computes the paths of all entries in a single
+// pass, parents are always indexed before their children
+func (idx *Index) buildPaths() {
+	idx.paths = make([]string, len(idx.Entries))
+	for i, e := range idx.Entries {
+		if e.Parent == noParent {
+			idx.paths[i] = e.Name
+			continue
+		}
+		idx.paths[i] = filepath.Join(idx.paths[e.Parent], e.Name)
+	}
+}
+
+// StorageEntries returns the indices of the entries of a storage
+func (idx *Index) StorageEntries(name string) []int {
+	if idx.byStorage == nil {
+		idx.byStorage = make(map[string][]int)
+		for i, e := range idx.Entries {
+			sto := idx.Storages[e.Storage]
+			idx.byStorage[sto] = append(idx.byStorage[sto], i)
+		}
+	}
+	return idx.byStorage[name]
+}
+
+// Resolve returns the node of the entry in the tree
+func (idx *Index) Resolve(t *tree.Tree, pos int) node.Node {
+	return idx.resolve(t, int32(pos))
+}
+
+func (idx *Index) resolve(t *tree.Tree, pos int32) node.Node {
+	if n, ok := idx.resolved[pos]; ok {
+		return n
+	}
+	e := idx.Entries[pos]
+	var parent node.Node
+	if e.Parent == noParent {
+		storage := t.GetStorageByName(idx.Storages[e.Storage])
+		if storage == nil {
+			return nil
+		}
+		parent = storage
+	} else {
+		parent = idx.resolve(t, e.Parent)
+		if parent == nil {
+			return nil
+		}
+	}
+	child, ok := parent.GetDirectChildren()[e.Name]
+	if !ok {
+		return nil
+	}
+	if idx.resolved == nil {
+		idx.resolved = make(map[int32]node.Node)
+	}
+	idx.resolved[pos] = child
+	return child
+}
@@ -0,0 +1,394 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package server
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/deadc0de6/gocatcli/internal/helpers"
+	"github.com/deadc0de6/gocatcli/internal/log"
+	"github.com/deadc0de6/gocatcli/internal/node"
+	"github.com/deadc0de6/gocatcli/internal/query"
+	"github.com/deadc0de6/gocatcli/internal/tree"
+)
+
+const (
+	// DefaultMaxResults max number of entries returned by find
+	DefaultMaxResults = 1000
+)
+
+var (
+	//go:embed static
+	staticFiles embed.FS
+)
+
+// Loader loads the catalog tree
+type Loader func() (*tree.Tree, error)
+
+// Server serves the catalog read-only over http
+type Server struct {
+	catalogPaths []string
+	loader       Loader
+	MaxResults   int
+
+	// nodes are sorted in place when listed,
+	// the tree is thus only accessed by one request at a time
+	mu       sync.Mutex
+	theTree  *tree.Tree
+	stamp    string
+	loadedAt time.Time
+}
+
+// apiEntry a node as returned by the api
+type apiEntry struct {
+	Name     string            `json:"name"`
+	Path     string            `json:"path"`
+	Storage  string            `json:"storage"`
+	Type     string            `json:"type"`
+	Size     uint64            `json:"size"`
+	Maccess  int64             `json:"maccess,omitempty"`
+	Attrs    map[string]string `json:"attrs,omitempty"`
+	Children []*apiEntry       `json:"children,omitempty"`
+}
+
+// apiFind the result of find
+type apiFind struct {
+	Entries   []*apiEntry `json:"entries"`
+	Truncated bool        `json:"truncated"`
+}
+
+// apiInfo the catalog information
+type apiInfo struct {
+	Catalog  string `json:"catalog"`
+	LoadedAt int64  `json:"loaded_at"`
+	Storages int    `json:"storages"`
+}
+
+// apiError an error returned by the api
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// stamp identifies a revision of the catalog files
+func stamp(paths []string) string {
+	var stamps []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return ""
+		}
+		stamps = append(stamps, fmt.Sprintf("%d-%d", info.Size(), info.ModTime().UnixNano()))
+	}
+	return strings.Join(stamps, ",")
+}
+
+// fullPath returns storage/path of a node
+func fullPath(t *tree.Tree, n node.Node) string {
+	if node.IsCollection(n) {
+		return n.GetPath()
+	}
+	sto := t.GetStorageNode(n)
+	if sto == nil || node.IsStorage(n) {
+		return n.GetName()
+	}
+	return filepath.Join(sto.GetName(), n.GetPath())
+}
+
+func toEntry(t *tree.Tree, n node.Node, withAttrs bool) *apiEntry {
+	e := &apiEntry{
+		Name:    n.GetName(),
+		Path:    fullPath(t, n),
+		Type:    string(n.GetType()),
+		Size:    n.GetSize(),
+		Maccess: n.GetMAccess(),
+	}
+	if sto := t.GetStorageNode(n); sto != nil {
+		e.Storage = sto.GetName()
+	}
+	if withAttrs {
+		e.Attrs = n.GetAttr(true, true)
+	}
+	return e
+}
+
+func writeJSON(w http.ResponseWriter, status int, content any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(content)
+	if err != nil {
+		log.Error(err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, &apiError{Error: err.Error()})
+}
+
+// isTrue returns true for "1", "true", etc
+func isTrue(value string) bool {
+	b, err := strconv.ParseBool(value)
+	return err == nil && b
+}
+
+// trimPath removes the trailing separators
+func trimPath(path string) string {
+	return strings.TrimRight(path, string(filepath.Separator))
+}
+
+// startNodes returns the nodes matching path or the storages
+func startNodes(t *tree.Tree, path string) []node.Node {
+	if len(path) < 1 {
+		var nodes []node.Node
+		for _, sto := range t.GetStorages() {
+			nodes = append(nodes, sto)
+		}
+		return nodes
+	}
+	return t.GetNodesFromPath(trimPath(path))
+}
+
+// withTree runs the handler with exclusive access to the tree
+func (s *Server) withTree(handler func(*tree.Tree, http.ResponseWriter, *http.Request)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("read-only api"))
+			return
+		}
+		log.Debugf("%s %s", r.Method, r.URL)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		handler(s.theTree, w, r)
+	}
+}
+
+func (s *Server) handleInfo(t *tree.Tree, w http.ResponseWriter, _ *http.Request) {
+	var names []string
+	for _, path := range s.catalogPaths {
+		names = append(names, filepath.Base(path))
+	}
+	writeJSON(w, http.StatusOK, &apiInfo{
+		Catalog:  strings.Join(names, ","),
+		LoadedAt: s.loadedAt.Unix(),
+		Storages: len(t.GetStorages()),
+	})
+}
+
+func (s *Server) handleStorages(t *tree.Tree, w http.ResponseWriter, _ *http.Request) {
+	entries := []*apiEntry{}
+	for _, sto := range t.GetStorages() {
+		entries = append(entries, toEntry(t, sto, true))
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (s *Server) handleLs(t *tree.Tree, w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Query().Get("path")
+	showAll := isTrue(r.URL.Query().Get("all"))
+	nodes := startNodes(t, path)
+	if len(nodes) < 1 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no such path: \"%s\"", path))
+		return
+	}
+
+	entries := []*apiEntry{}
+	for _, n := range nodes {
+		e := toEntry(t, n, true)
+		t.ProcessChildren(n, showAll, func(child node.Node, _ int, _ node.Node) bool {
+			e.Children = append(e.Children, toEntry(t, child, false))
+			return true
+		}, 0)
+		entries = append(entries, e)
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (s *Server) handleNode(t *tree.Tree, w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Query().Get("path")
+	if len(path) < 1 {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("missing path"))
+		return
+	}
+	nodes := t.GetNodesFromPath(trimPath(path))
+	if len(nodes) < 1 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no such path: \"%s\"", path))
+		return
+	}
+	entries := []*apiEntry{}
+	for _, n := range nodes {
+		entries = append(entries, toEntry(t, n, true))
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+func (s *Server) handleFind(t *tree.Tree, w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+	pattern := params.Get("pattern")
+	var re *regexp.Regexp
+	if len(pattern) > 0 {
+		var err error
+		re, err = regexp.Compile(helpers.PatchPattern(pattern))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	var q *query.Query
+	if len(params.Get("query")) > 0 {
+		var err error
+		q, err = query.Parse(params.Get("query"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if re == nil && q == nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("missing pattern or query"))
+		return
+	}
+	limit := s.MaxResults
+	if value := params.Get("limit"); len(value) > 0 {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("bad limit \"%s\"", value))
+			return
+		}
+	}
+
+	path := params.Get("path")
+	nodes := startNodes(t, path)
+	if len(nodes) < 1 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no such path: \"%s\"", path))
+		return
+	}
+
+	result := &apiFind{Entries: []*apiEntry{}}
+	for _, start := range nodes {
+		t.ProcessChildren(start, true, func(n node.Node, _ int, _ node.Node) bool {
+			if result.Truncated {
+				return false
+			}
+			if re != nil && !re.MatchString(n.GetName()) {
+				return true
+			}
+			if q != nil && !q.Match(n, t.GetStorageNode(n)) {
+				return true
+			}
+			if len(result.Entries) >= limit {
+				result.Truncated = true
+				return false
+			}
+			result.Entries = append(result.Entries, toEntry(t, n, false))
+			return true
+		}, -1)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *Server) handleDu(t *tree.Tree, w http.ResponseWriter, r *http.Request) {
+	path := r.URL.Query().Get("path")
+	depth := -1
+	if value := r.URL.Query().Get("depth"); len(value) > 0 {
+		var err error
+		depth, err = strconv.Atoi(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("bad depth \"%s\"", value))
+			return
+		}
+	}
+	nodes := startNodes(t, path)
+	if len(nodes) < 1 {
+		writeError(w, http.StatusNotFound, fmt.Errorf("no such path: \"%s\"", path))
+		return
+	}
+
+	entries := []*apiEntry{}
+	for _, start := range nodes {
+		t.ProcessChildren(start, true, func(n node.Node, _ int, _ node.Node) bool {
+			if node.IsDir(n) {
+				entries = append(entries, toEntry(t, n, false))
+			}
+			return true
+		}, depth)
+		entries = append(entries, toEntry(t, start, false))
+	}
+	writeJSON(w, http.StatusOK, entries)
+}
+
+// Handler returns the http handler of the api and the web ui
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/info", s.withTree(s.handleInfo))
+	mux.HandleFunc("/api/storages", s.withTree(s.handleStorages))
+	mux.HandleFunc("/api/ls", s.withTree(s.handleLs))
+	mux.HandleFunc("/api/node", s.withTree(s.handleNode))
+	mux.HandleFunc("/api/find", s.withTree(s.handleFind))
+	mux.HandleFunc("/api/du", s.withTree(s.handleDu))
+
+	ui, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		log.Fatal(err)
+	}
+	mux.Handle("/", http.FileServer(http.FS(ui)))
+	return mux
+}
+
+// Reload reloads the catalog if it changed on disk
+func (s *Server) Reload() {
+	current := stamp(s.catalogPaths)
+	s.mu.Lock()
+	changed := current != s.stamp
+	s.mu.Unlock()
+	if !changed || len(current) < 1 {
+		return
+	}
+
+	log.Debugf("catalog \"%s\" changed, reloading", strings.Join(s.catalogPaths, ","))
+	t, err := s.loader()
+	if err != nil {
+		log.Errorf("reloading catalog failed: %v", err)
+		return
+	}
+
+	s.mu.Lock()
+	s.theTree = t
+	s.stamp = current
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+	log.Infof("catalog \"%s\" reloaded", strings.Join(s.catalogPaths, ","))
+}
+
+// Watch reloads the catalog every interval when it changed
+func (s *Server) Watch(interval time.Duration) {
+	for range time.Tick(interval) {
+		s.Reload()
+	}
+}
+
+// NewServer creates a new server for the catalog(s)
+// loaded in t, loader is used to reload them
+func NewServer(catalogPaths []string, t *tree.Tree, loader Loader) *Server {
+	s := Server{
+		catalogPaths: catalogPaths,
+		loader:       loader,
+		MaxResults:   DefaultMaxResults,
+		theTree:      t,
+		stamp:        stamp(catalogPaths),
+		loadedAt:     time.Now(),
+	}
+	return &s
+}
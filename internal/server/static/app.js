@@ -0,0 +1,186 @@
+// web ui of "gocatcli serve", the location hash
+// is the browsed path (#storage/dir/sub)
+(function () {
+  'use strict';
+
+  var units = ['', 'KB', 'MB', 'GB', 'TB', 'PB'];
+
+  function humanSize(size) {
+    var i = 0;
+    while (size >= 1024 && i < units.length - 1) {
+      size /= 1024;
+      i++;
+    }
+    return Math.round(size) + units[i];
+  }
+
+  function humanDate(seconds) {
+    if (!seconds) {
+      return '';
+    }
+    return new Date(seconds * 1000).toLocaleString();
+  }
+
+  function el(tag, text, cls) {
+    var e = document.createElement(tag);
+    if (text !== undefined) {
+      e.textContent = text;
+    }
+    if (cls) {
+      e.className = cls;
+    }
+    return e;
+  }
+
+  function api(endpoint, params) {
+    var url = 'api/' + endpoint + '?' + new URLSearchParams(params).toString();
+    return fetch(url).then(function (resp) {
+      return resp.json().then(function (content) {
+        if (!resp.ok) {
+          throw new Error(content.error || resp.statusText);
+        }
+        return content;
+      });
+    });
+  }
+
+  function showError(err) {
+    var e = document.getElementById('error');
+    e.textContent = err ? err.message : '';
+    e.hidden = !err;
+  }
+
+  function hasChildren(entry) {
+    return entry.type === 'collection' || entry.type === 'storage' || entry.type === 'dir' || entry.type === 'archive';
+  }
+
+  function link(entry, text) {
+    var a = el('a', text || entry.name);
+    if (hasChildren(entry)) {
+      a.href = '#' + encodeURIComponent(entry.path);
+    } else {
+      a.href = '#';
+      a.addEventListener('click', function (ev) {
+        ev.preventDefault();
+        showDetails(entry.path);
+      });
+    }
+    return a;
+  }
+
+  function breadcrumbs(path) {
+    var nav = document.getElementById('breadcrumbs');
+    nav.textContent = '';
+    var root = el('a', 'storages');
+    root.href = '#';
+    nav.appendChild(root);
+    var cur = '';
+    path.split('/').filter(Boolean).forEach(function (part) {
+      cur = cur ? cur + '/' + part : part;
+      nav.appendChild(document.createTextNode(' / '));
+      var a = el('a', part);
+      a.href = '#' + encodeURIComponent(cur);
+      nav.appendChild(a);
+    });
+  }
+
+  function fillEntries(entries, fullPath) {
+    var tbody = document.getElementById('entries');
+    tbody.textContent = '';
+    entries.forEach(function (entry) {
+      var row = el('tr', undefined, entry.type);
+      var name = el('td');
+      name.appendChild(link(entry, fullPath ? entry.path : entry.name));
+      row.appendChild(name);
+      row.appendChild(el('td', entry.type));
+      row.appendChild(el('td', humanSize(entry.size), 'num'));
+      row.appendChild(el('td', humanDate(entry.maccess)));
+      tbody.appendChild(row);
+    });
+    if (entries.length === 0) {
+      var row = el('tr');
+      var td = el('td', 'empty');
+      td.colSpan = 4;
+      row.appendChild(td);
+      tbody.appendChild(row);
+    }
+  }
+
+  function showDetails(path) {
+    api('node', { path: path }).then(function (entries) {
+      var div = document.getElementById('details');
+      div.textContent = '';
+      entries.forEach(function (entry) {
+        div.appendChild(el('h3', entry.path));
+        var table = el('table', undefined, 'summary');
+        Object.keys(entry.attrs || {}).sort().forEach(function (key) {
+          var row = el('tr');
+          row.appendChild(el('th', key));
+          row.appendChild(el('td', entry.attrs[key]));
+          table.appendChild(row);
+        });
+        div.appendChild(table);
+      });
+      div.hidden = false;
+      showError(null);
+    }).catch(showError);
+  }
+
+  function browse(path) {
+    document.getElementById('details').hidden = true;
+    breadcrumbs(path);
+    document.getElementById('title').textContent = path || 'storages';
+    var req = path ? api('ls', { path: path }) : api('storages', {});
+    req.then(function (entries) {
+      if (path) {
+        var children = [];
+        entries.forEach(function (entry) {
+          children = children.concat(entry.children || []);
+        });
+        if (entries.length === 1 && entries[0].type === 'storage') {
+          showDetails(entries[0].path);
+        }
+        entries = children;
+      }
+      fillEntries(entries, false);
+      showError(null);
+    }).catch(showError);
+  }
+
+  function find(pattern, q) {
+    document.getElementById('details').hidden = true;
+    var params = {};
+    if (pattern) {
+      params.pattern = pattern;
+    }
+    if (q) {
+      params.query = q;
+    }
+    api('find', params).then(function (result) {
+      var title = result.entries.length + ' result(s)';
+      if (result.truncated) {
+        title += ' (truncated)';
+      }
+      document.getElementById('title').textContent = title;
+      fillEntries(result.entries, true);
+      showError(null);
+    }).catch(showError);
+  }
+
+  function route() {
+    browse(decodeURIComponent(window.location.hash.substring(1)));
+  }
+
+  document.getElementById('search-form').addEventListener('submit', function (ev) {
+    ev.preventDefault();
+    find(document.getElementById('search').value, document.getElementById('query').value);
+  });
+  window.addEventListener('hashchange', route);
+
+  api('info', {}).then(function (info) {
+    document.title = 'gocatcli - ' + info.catalog;
+    document.getElementById('info').textContent = info.catalog + ', ' +
+      info.storages + ' storage(s), loaded ' + humanDate(info.loaded_at);
+  }).catch(showError);
+  route();
+})();
@@ -62,6 +62,23 @@ func (p *CSVStringer) storageToString(storage *node.StorageNode) string {
 	return strings.Join(fields, p.mode.Separator)
 }
 
+func (p *CSVStringer) collectionToString(n node.Node) string {
+	var fields []string
+	fields = append(fields, n.GetName())
+	fields = append(fields, string(n.GetType()))
+	fields = append(fields, n.GetPath())
+	fields = append(fields, p.getSize(n.GetSize()))
+	fields = append(fields, "") // indexed_at
+	fields = append(fields, "") // maccess
+	fields = append(fields, "") // checksum
+	fields = append(fields, "") // nbfiles
+	fields = append(fields, "") // free_space
+	fields = append(fields, "") // total_space
+	fields = append(fields, "") // meta
+	fields = append(fields, "") // storage
+	return strings.Join(fields, p.mode.Separator)
+}
+
 func (p *CSVStringer) fileToString(n *node.FileNode) string {
 	var fields []string
 	fields = append(fields, n.Name)
@@ -72,7 +89,7 @@ func (p *CSVStringer) fileToString(n *node.FileNode) string {
 	maccess := helpers.DateToString(n.Maccess)
 	fields = append(fields, maccess)
 	fields = append(fields, string(n.Checksum))
-	fields = append(fields, fmt.Sprintf("%d", len(n.Children)))
+	fields = append(fields, fmt.Sprintf("%d", len(n.GetDirectChildren())))
 	fields = append(fields, "") // free_space
 	fields = append(fields, "") // total_space
 	fields = append(fields, "") // meta
@@ -92,9 +109,12 @@ func (p *CSVStringer) ToString(n node.Node, _ int) *Entry {
 
 	entry.Name = n.GetName()
 	entry.Node = n
-	if n.GetType() == node.FileTypeStorage {
+	switch n.GetType() {
+	case node.FileTypeStorage:
 		entry.Line = p.storageToString(n.(*node.StorageNode))
-	} else {
+	case node.FileTypeCollection:
+		entry.Line = p.collectionToString(n)
+	default:
 		entry.Line = p.fileToString(n.(*node.FileNode))
 	}
 	return &entry
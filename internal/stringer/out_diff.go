@@ -0,0 +1,232 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package stringer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/deadc0de6/gocatcli/internal/colorme"
+	"github.com/deadc0de6/gocatcli/internal/differ"
+	"github.com/deadc0de6/gocatcli/internal/helpers"
+	"github.com/deadc0de6/gocatcli/internal/node"
+)
+
+var (
+	diffHeader = []string{
+		"storage",
+		"change",
+		"path",
+		"old_path",
+		"size_delta",
+		"reasons",
+	}
+)
+
+// diffChangeJSON a change in json
+type diffChangeJSON struct {
+	Type      string   `json:"change"`
+	Path      string   `json:"path"`
+	OldPath   string   `json:"old_path,omitempty"`
+	SizeDelta int64    `json:"size_delta"`
+	Reasons   []string `json:"reasons,omitempty"`
+}
+
+// diffStorageJSON a storage diff in json
+type diffStorageJSON struct {
+	Storage   string            `json:"storage"`
+	Type      string            `json:"change"`
+	OldSize   uint64            `json:"old_size"`
+	NewSize   uint64            `json:"new_size"`
+	SizeDelta int64             `json:"size_delta"`
+	Changes   []*diffChangeJSON `json:"changes"`
+}
+
+// DiffStringer printer for the differences between trees
+type DiffStringer struct {
+	format   string
+	mode     *PrintMode
+	cm       *colorme.ColorMe
+	storages []*diffStorageJSON
+}
+
+// GetDiffFormats returns the formats supported for diffs
+func GetDiffFormats() []string {
+	return []string{
+		FormatNative,
+		FormatCSV,
+		FormatCSVWithHeader,
+		FormatJSON,
+	}
+}
+
+func (p *DiffStringer) getDelta(delta int64) string {
+	sign := "+"
+	abs := uint64(delta)
+	if delta < 0 {
+		sign = "-"
+		abs = uint64(-delta)
+	}
+	if p.mode.RawSize {
+		return fmt.Sprintf("%s%d", sign, abs)
+	}
+	return sign + helpers.SizeToHuman(abs)
+}
+
+// storageChange returns the type of change of the storage
+func storageChange(d *differ.StorageDiff) string {
+	if d.Old == nil {
+		return differ.ChangeAdded
+	}
+	if d.New == nil {
+		return differ.ChangeRemoved
+	}
+	return differ.ChangeChanged
+}
+
+// changeDelta returns the size difference of the entry
+func changeDelta(c *differ.Change) int64 {
+	var oldSize, newSize uint64
+	if c.Old != nil && !node.IsDir(c.Old) {
+		oldSize = c.Old.GetSize()
+	}
+	if c.New != nil && !node.IsDir(c.New) {
+		newSize = c.New.GetSize()
+	}
+	return int64(newSize) - int64(oldSize)
+}
+
+func (p *DiffStringer) colorChange(typ string) string {
+	txt := fmt.Sprintf("%-8s", typ)
+	switch typ {
+	case differ.ChangeAdded:
+		return p.cm.InGreen(txt)
+	case differ.ChangeRemoved:
+		return p.cm.InRed(txt)
+	case differ.ChangeMoved:
+		return p.cm.InBlue(txt)
+	default:
+		return p.cm.InYellow(txt)
+	}
+}
+
+func (p *DiffStringer) printNative(d *differ.StorageDiff) {
+	line := fmt.Sprintf("%s %s %s", p.cm.InUnderline(p.cm.InGray(nativeStorageName)),
+		p.cm.InPurple(d.Name), p.cm.InGray(fmt.Sprintf("(%s, %s)", storageChange(d), p.getDelta(d.SizeDelta()))))
+	fmt.Println(line)
+	for _, c := range d.Changes {
+		line := nativeIndentString + p.colorChange(c.Type) + " "
+		if c.Type == differ.ChangeMoved {
+			line += fmt.Sprintf("%s -> %s", c.Old.GetPath(), c.Path)
+		} else {
+			line += c.Path
+		}
+		if delta := changeDelta(c); delta != 0 {
+			line += " " + p.cm.InGreen(p.getDelta(delta))
+		}
+		if len(c.Reasons) > 0 {
+			line += " " + p.cm.InGray(fmt.Sprintf("(%s)", strings.Join(c.Reasons, ",")))
+		}
+		fmt.Println(line)
+	}
+}
+
+func (p *DiffStringer) printCSV(d *differ.StorageDiff) {
+	fields := []string{d.Name, storageChange(d), "", "", p.getDelta(d.SizeDelta()), ""}
+	fmt.Println(strings.Join(fields, p.mode.Separator))
+	for _, c := range d.Changes {
+		var oldPath string
+		if c.Type == differ.ChangeMoved {
+			oldPath = c.Old.GetPath()
+		}
+		fields := []string{
+			d.Name,
+			c.Type,
+			c.Path,
+			oldPath,
+			p.getDelta(changeDelta(c)),
+			strings.Join(c.Reasons, " "),
+		}
+		fmt.Println(strings.Join(fields, p.mode.Separator))
+	}
+}
+
+func (p *DiffStringer) addJSON(d *differ.StorageDiff) {
+	entry := &diffStorageJSON{
+		Storage:   d.Name,
+		Type:      storageChange(d),
+		SizeDelta: d.SizeDelta(),
+		Changes:   []*diffChangeJSON{},
+	}
+	if d.Old != nil {
+		entry.OldSize = d.Old.GetSize()
+	}
+	if d.New != nil {
+		entry.NewSize = d.New.GetSize()
+	}
+	for _, c := range d.Changes {
+		change := &diffChangeJSON{
+			Type:      c.Type,
+			Path:      c.Path,
+			SizeDelta: changeDelta(c),
+			Reasons:   c.Reasons,
+		}
+		if c.Type == differ.ChangeMoved {
+			change.OldPath = c.Old.GetPath()
+		}
+		entry.Changes = append(entry.Changes, change)
+	}
+	p.storages = append(p.storages, entry)
+}
+
+// PrintPrefix prints the header
+func (p *DiffStringer) PrintPrefix() {
+	if p.format == FormatCSVWithHeader {
+		fmt.Println(strings.Join(diffHeader, p.mode.Separator))
+	}
+}
+
+// Print prints the differences of a storage
+func (p *DiffStringer) Print(d *differ.StorageDiff) {
+	switch p.format {
+	case FormatCSV, FormatCSVWithHeader:
+		p.printCSV(d)
+	case FormatJSON:
+		p.addJSON(d)
+	default:
+		p.printNative(d)
+	}
+}
+
+// PrintSuffix prints the json document
+func (p *DiffStringer) PrintSuffix() error {
+	if p.format != FormatJSON {
+		return nil
+	}
+	if p.storages == nil {
+		p.storages = []*diffStorageJSON{}
+	}
+	content, err := json.MarshalIndent(p.storages, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(content))
+	return nil
+}
+
+// NewDiffStringer creates a new diff printer
+func NewDiffStringer(format string, mode *PrintMode) (*DiffStringer, error) {
+	if helpers.NotIn(format, GetDiffFormats()) {
+		return nil, fmt.Errorf("not such format: %s", format)
+	}
+	p := DiffStringer{
+		format: format,
+		mode:   mode,
+		cm:     colorme.NewColorme(mode.InlineColor),
+	}
+	return &p, nil
+}
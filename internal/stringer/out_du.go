@@ -37,8 +37,8 @@ func (p *DuString) ToString(n node.Node, _ int, _ bool) *Entry {
 	var entry Entry
 
 	prePath := ""
-	if !node.IsStorage(n) {
-		prePath = p.theTree.GetStorageNode(n).GetName()
+	if sto := p.theTree.GetStorageNode(n); sto != nil && !node.IsStorage(n) {
+		prePath = sto.GetName()
 	}
 	path := filepath.Join(prePath, n.GetPath())
 	entry.Name = path
@@ -0,0 +1,184 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package stringer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/deadc0de6/gocatcli/internal/log"
+	"github.com/deadc0de6/gocatcli/internal/node"
+	"github.com/deadc0de6/gocatcli/internal/tree"
+)
+
+// jsonEntry all the fields of a node
+type jsonEntry struct {
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	Type         string            `json:"type"`
+	Path         string            `json:"path"`
+	Storage      string            `json:"storage"`
+	Size         uint64            `json:"size"`
+	Maccess      int64             `json:"maccess,omitempty"`
+	IndexedAt    int64             `json:"indexed_at"`
+	Mode         string            `json:"mode,omitempty"`
+	Mime         string            `json:"mime,omitempty"`
+	Checksum     string            `json:"checksum,omitempty"`
+	Extra        map[string]string `json:"extra,omitempty"`
+	Children     int               `json:"children,omitempty"`
+	Tags         []string          `json:"tags,omitempty"`
+	Note         string            `json:"note,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	Meta         string            `json:"meta,omitempty"`
+	Free         uint64            `json:"free,omitempty"`
+	Total        uint64            `json:"total,omitempty"`
+	TotalFiles   uint64            `json:"nbfiles,omitempty"`
+	ChecksumAlgo string            `json:"checksum_algo,omitempty"`
+	Collection   string            `json:"collection,omitempty"`
+}
+
+// JSONStringer prints one json object per node, either
+// one per line (ndjson) or as an indented json array
+type JSONStringer struct {
+	theTree *tree.Tree
+	mode    *PrintMode
+	ndjson  bool
+	cnt     int
+}
+
+// parseExtra parses the "<key>:<value>" comma separated list
+func parseExtra(extra string) map[string]string {
+	if len(extra) < 1 {
+		return nil
+	}
+	fields := make(map[string]string)
+	for _, field := range strings.Split(extra, ",") {
+		key, value, _ := strings.Cut(field, ":")
+		fields[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return fields
+}
+
+func (p *JSONStringer) toEntry(n node.Node) *jsonEntry {
+	if storage, ok := n.(*node.StorageNode); ok {
+		return &jsonEntry{
+			ID:           fmt.Sprintf("%d", storage.ID),
+			Name:         storage.GetName(),
+			Type:         string(storage.GetType()),
+			Path:         storage.Path,
+			Storage:      storage.GetName(),
+			Size:         storage.GetSize(),
+			IndexedAt:    storage.IndexedAt,
+			Children:     len(storage.GetDirectChildren()),
+			Tags:         storage.Tags,
+			Meta:         storage.Meta,
+			Free:         storage.Free,
+			Total:        storage.Total,
+			TotalFiles:   storage.TotalFiles,
+			ChecksumAlgo: storage.ChecksumAlgo,
+			Collection:   storage.Collection,
+		}
+	}
+	if node.IsCollection(n) {
+		return &jsonEntry{
+			Name:      n.GetName(),
+			Type:      string(n.GetType()),
+			Path:      n.GetPath(),
+			Size:      n.GetSize(),
+			IndexedAt: n.GetMAccess(),
+		}
+	}
+
+	f := n.(*node.FileNode)
+	entry := &jsonEntry{
+		ID:        f.ID,
+		Name:      f.GetName(),
+		Type:      string(f.GetType()),
+		Path:      f.GetPath(),
+		Size:      f.GetSize(),
+		Maccess:   f.Maccess,
+		IndexedAt: f.IndexedAt,
+		Mode:      f.Mode,
+		Mime:      f.Mime,
+		Checksum:  f.Checksum,
+		Extra:     parseExtra(f.Extra),
+		Tags:      f.Tags,
+		Note:      f.Note,
+		Metadata:  f.Metadata,
+	}
+	if node.MayHaveChildren(f) {
+		entry.Children = len(f.GetDirectChildren())
+	}
+	if sto := p.theTree.GetStorageNode(f); sto != nil {
+		entry.Storage = sto.GetName()
+	}
+	return entry
+}
+
+// ToString converts node to json for printing
+func (p *JSONStringer) ToString(n node.Node, _ int) *Entry {
+	var entry Entry
+	entry.Name = n.GetName()
+	entry.Node = n
+
+	var content []byte
+	var err error
+	if p.ndjson {
+		content, err = json.Marshal(p.toEntry(n))
+	} else {
+		content, err = json.MarshalIndent(p.toEntry(n), "  ", "  ")
+	}
+	if err != nil {
+		log.Error(err)
+		return &entry
+	}
+	entry.Line = string(content)
+	return &entry
+}
+
+// PrintPrefix opens the json array
+func (p *JSONStringer) PrintPrefix() {
+	p.cnt = 0
+	if !p.ndjson {
+		fmt.Println("[")
+	}
+}
+
+// PrintSuffix closes the json array
+func (p *JSONStringer) PrintSuffix() {
+	if p.ndjson {
+		return
+	}
+	if p.cnt > 0 {
+		fmt.Println()
+	}
+	fmt.Println("]")
+}
+
+// Print prints a node
+func (p *JSONStringer) Print(n node.Node, depth int) {
+	e := p.ToString(n, depth)
+	if p.ndjson {
+		fmt.Println(e.Line)
+		return
+	}
+	if p.cnt > 0 {
+		fmt.Println(",")
+	}
+	fmt.Print("  " + e.Line)
+	p.cnt++
+}
+
+// NewJSONStringer creates a new json printer
+func NewJSONStringer(t *tree.Tree, mode *PrintMode, ndjson bool) *JSONStringer {
+	p := JSONStringer{
+		theTree: t,
+		mode:    mode,
+		ndjson:  ndjson,
+	}
+	return &p
+}
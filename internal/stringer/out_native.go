@@ -16,8 +16,9 @@ import (
 )
 
 const (
-	nativeStorageName  = "storage"
-	nativeIndentString = "  "
+	nativeStorageName    = "storage"
+	nativeCollectionName = "collection"
+	nativeIndentString   = "  "
 )
 
 // NativeStringer printer struct
@@ -43,6 +44,26 @@ func (p *NativeStringer) storageToString(storage *node.StorageNode, pre string)
 	return out
 }
 
+func (p *NativeStringer) collectionToString(n node.Node, pre string) string {
+	out := pre
+	// "collection"
+	out += p.cm.InUnderline(p.cm.InGray(nativeCollectionName))
+	// the collection name
+	name := n.GetName()
+	if p.mode.FullPath {
+		name = n.GetPath()
+	}
+	out += " "
+	out += p.cm.InPurple(fmt.Sprintf("%-17s", name))
+
+	// add attributes
+	attrs := n.GetAttr(p.mode.RawSize, p.mode.Long)
+	if len(attrs) > 0 {
+		out += " " + AttrsToString(attrs, p.mode, " ")
+	}
+	return out
+}
+
 func (p *NativeStringer) fileToString(n node.Node, pre string) string {
 	out := pre
 
@@ -85,9 +106,12 @@ func (p *NativeStringer) ToString(n node.Node, depth int) *Entry {
 
 	entry.Name = n.GetName()
 	entry.Node = n
-	if n.GetType() == node.FileTypeStorage {
+	switch n.GetType() {
+	case node.FileTypeStorage:
 		entry.Line = p.storageToString(n.(*node.StorageNode), pre)
-	} else {
+	case node.FileTypeCollection:
+		entry.Line = p.collectionToString(n, pre)
+	default:
 		entry.Line = p.fileToString(n, pre)
 	}
 	return &entry
@@ -27,7 +27,7 @@ func (p *ScriptStringer) Print(n node.Node, _ int) {
 	if typ == node.FileTypeArchived {
 		return
 	}
-	if typ == node.FileTypeStorage {
+	if typ == node.FileTypeStorage || typ == node.FileTypeCollection {
 		return
 	}
 	fmt.Printf("\"${source}/%s\" ", n.GetPath())
@@ -7,6 +7,7 @@ package stringer
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/deadc0de6/gocatcli/internal/log"
 	"github.com/deadc0de6/gocatcli/internal/node"
@@ -20,6 +21,9 @@ import (
 type TreeStringer struct {
 	mode        *PrintMode
 	listOfTrees []*aTree
+	// collection at the top of the current tree
+	inCollection bool
+	collection   string
 }
 
 type aTree struct {
@@ -37,6 +41,16 @@ func (p *TreeStringer) storageToString(storage *node.StorageNode) string {
 	return out
 }
 
+func (p *TreeStringer) collectionToString(n node.Node) string {
+	out := color.InUnderline(color.InGray(nativeCollectionName))
+	out += " " + color.InPurple(n.GetName())
+	attrs := n.GetAttr(false, p.mode.Long)
+	if len(attrs) > 0 {
+		out += " " + AttrsToString(attrs, p.mode, " ")
+	}
+	return out
+}
+
 func (p *TreeStringer) fileToString(n node.Node) string {
 	var out string
 	name := fmt.Sprintf("%-20s", n.GetName())
@@ -48,6 +62,28 @@ func (p *TreeStringer) fileToString(n node.Node) string {
 	return out
 }
 
+// isTop returns true if the node starts a new tree,
+// storages and collections are part of the tree of
+// the collection they belong to
+func (p *TreeStringer) isTop(n node.Node) bool {
+	var collection string
+	switch {
+	case node.IsCollection(n):
+		collection = n.GetPath()
+	case node.IsStorage(n):
+		collection = n.(*node.StorageNode).Collection
+	default:
+		return false
+	}
+
+	if p.inCollection && (collection == p.collection || strings.HasPrefix(collection, p.collection+node.CollectionSeparator)) {
+		return false
+	}
+	p.inCollection = node.IsCollection(n)
+	p.collection = collection
+	return true
+}
+
 // Print adds the node to the accumulator
 func (p *TreeStringer) Print(n node.Node, depth int) {
 	if n == nil {
@@ -55,15 +91,15 @@ func (p *TreeStringer) Print(n node.Node, depth int) {
 		return
 	}
 
-	isStorage := node.IsStorage(n)
-	if isStorage || len(p.listOfTrees) < 1 {
+	isTop := p.isTop(n)
+	if isTop || len(p.listOfTrees) < 1 {
 		// new tree
 		atree := &aTree{}
 		p.listOfTrees = append(p.listOfTrees, atree)
 	}
 
 	lastTree := p.listOfTrees[len(p.listOfTrees)-1]
-	if isStorage || p.mode.FullPath {
+	if isTop || p.mode.FullPath {
 		// add storage or first node as top level
 		e := p.ToString(n, depth)
 		lastTree.headerLine = e.Line
@@ -91,9 +127,12 @@ func (p *TreeStringer) ToString(n node.Node, _ int) *Entry {
 
 	entry.Name = n.GetName()
 	entry.Node = n
-	if node.IsStorage(n) {
+	switch {
+	case node.IsStorage(n):
 		entry.Line = p.storageToString(n.(*node.StorageNode))
-	} else {
+	case node.IsCollection(n):
+		entry.Line = p.collectionToString(n)
+	default:
 		entry.Line = p.fileToString(n)
 	}
 	return &entry
@@ -119,6 +158,7 @@ func (p *TreeStringer) PrintSuffix() {
 
 	// clear
 	p.listOfTrees = []*aTree{}
+	p.inCollection = false
 }
 
 // NewTreeStringer creates a new tree printer
@@ -26,6 +26,10 @@ const (
 	FormatTree = "tree"
 	// FormatDebug debug output
 	FormatDebug = "debug"
+	// FormatJSON json document
+	FormatJSON = "json"
+	// FormatNDJSON one json object per line
+	FormatNDJSON = "ndjson"
 )
 
 // Entry entries when traversing the tree
@@ -68,6 +72,10 @@ func GetStringer(tree *tree.Tree, format string, mode *PrintMode) (Stringer, err
 		stringGetter = NewTreeStringer(mode)
 	case FormatDebug:
 		stringGetter = NewDebugStringer(tree, mode)
+	case FormatJSON:
+		stringGetter = NewJSONStringer(tree, mode, false)
+	case FormatNDJSON:
+		stringGetter = NewJSONStringer(tree, mode, true)
 	default:
 		return nil, fmt.Errorf("not such format: %s", format)
 	}
@@ -80,6 +88,8 @@ func GetSupportedFormats(treeOk bool, scriptOk bool) []string {
 		FormatNative,
 		FormatCSV,
 		FormatCSVWithHeader,
+		FormatJSON,
+		FormatNDJSON,
 		FormatDebug,
 	}
 	if treeOk {
@@ -6,7 +6,9 @@ Copyright (c) 2024, deadc0de6
 package tree
 
 import (
+	"fmt"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
@@ -17,6 +19,11 @@ import (
 
 const (
 	toolName = "gocatcli - https://github.com/deadc0de6/gocatcli"
+	// SchemaVersion the current catalog schema version
+	SchemaVersion = 6
+	// FederationSeparator separates the catalog name
+	// from the storage name in a federated tree
+	FederationSeparator = ":"
 )
 
 // Tree the tree
@@ -24,10 +31,12 @@ type Tree struct {
 	Storages []*node.StorageNode `json:"storages" toml:"storages"`
 	Tool     string              `json:"tool" toml:"tool"`
 	Version  string              `json:"version" toml:"version"`
+	Schema   int                 `json:"schema" toml:"schema"`
 	Created  int64               `json:"created" toml:"created"`
 	Updated  int64               `json:"updated" toml:"updated"`
 	Note     string              `json:"note" toml:"note"`
 	//Nodes    map[string]*node.FileNode `json:"-" toml:"-"`
+	origins map[*node.StorageNode]int // federated catalog of the storages
 }
 
 // ProcessCallback will be called with the current node, its depth and its parent
@@ -42,12 +51,29 @@ func matchPath(current node.Node, path string) bool {
 	return true
 }
 
-// recursively find nodes matching path entries
+// recursively find nodes matching path entries,
+// a "**" entry matches zero or more directories
 func (t *Tree) descendNodeWithPath(current node.Node, paths []string) []node.Node {
 	if len(paths) < 1 {
 		return nil
 	}
 
+	if paths[0] == helpers.DoubleStar {
+		if len(paths) == 1 {
+			// matches everything below
+			return append([]node.Node{current}, t.ProcessChildren(current, true, nil, -1)...)
+		}
+		// matches zero directory
+		subs := t.descendNodeWithPath(current, paths[1:])
+		if node.IsDir(current) {
+			// or this directory and maybe more
+			for _, child := range current.GetSortedDirectChildren() {
+				subs = append(subs, t.descendNodeWithPath(child, paths)...)
+			}
+		}
+		return subs
+	}
+
 	if !matchPath(current, paths[0]) {
 		return nil
 	}
@@ -68,9 +94,24 @@ func (t *Tree) descendNodeWithPath(current node.Node, paths []string) []node.Nod
 	return []node.Node{current}
 }
 
+// uniqNodes removes the nodes found multiple times
+func uniqNodes(nodes []node.Node) []node.Node {
+	seen := make(map[node.Node]bool)
+	var uniq []node.Node
+	for _, n := range nodes {
+		if seen[n] {
+			continue
+		}
+		seen[n] = true
+		uniq = append(uniq, n)
+	}
+	return uniq
+}
+
 // GetNodesFromPath returns all nodes which path match the path argument, nil otherwise
 // if storage is defined, will only look into its nodes
-// The path argument can use regexp/wildcards
+// The path argument can use regexp/wildcards and "**" to match
+// any number of directories (like "storage/**/photos/*.raw")
 func (t *Tree) GetNodesFromPath(path string) []node.Node {
 	log.Debugf("GetNodesFromPath for path \"%s\"", path)
 	if len(path) < 1 {
@@ -84,31 +125,156 @@ func (t *Tree) GetNodesFromPath(path string) []node.Node {
 		return nil
 	}
 
-	// find the storage nodes matching
-	tops := []node.Node{}
-	for _, top := range t.GetStorages() {
-		if matchPath(top, paths[0]) {
-			log.Debugf("selected top: %s", top.GetName())
-			tops = append(tops, top)
+	// find the top nodes (collections and storages) matching
+	var tops []*top
+	if paths[0] == helpers.DoubleStar {
+		// the storages are part of the "**"
+		for _, sto := range t.GetStorages() {
+			tops = append(tops, &top{sto, paths})
+		}
+	} else {
+		tops = t.matchTops("", paths)
+		for _, sto := range t.GetStorages() {
+			// storages are also reachable by name only
+			if len(sto.Collection) > 0 && matchPath(sto, paths[0]) {
+				tops = append(tops, &top{sto, paths[1:]})
+			}
 		}
 	}
 
 	var found []node.Node
-	if len(paths) > 1 {
-		for _, top := range tops {
-			for _, child := range top.GetDirectChildren() {
-				sub := t.descendNodeWithPath(child, paths[1:])
-				if sub != nil {
-					found = append(found, sub...)
-				}
+	var topNodes []node.Node
+	for _, top := range tops {
+		log.Debugf("selected top: %s", top.node.GetName())
+		topNodes = append(topNodes, top.node)
+		if len(top.subPaths) < 1 {
+			continue
+		}
+		for _, child := range top.node.GetSortedDirectChildren() {
+			sub := t.descendNodeWithPath(child, top.subPaths)
+			if sub != nil {
+				found = append(found, sub...)
+			}
+		}
+	}
+
+	if len(found) < 1 && !helpers.HasDoubleStar(path) {
+		return uniqNodes(topNodes)
+	}
+	return uniqNodes(found)
+}
+
+// top a collection or storage matched by a path
+// and the remaining path entries to match below it
+type top struct {
+	node     node.Node
+	subPaths []string
+}
+
+// matchTops matches the path entries against the
+// collections and storages found in collection
+func (t *Tree) matchTops(collection string, paths []string) []*top {
+	var tops []*top
+	for _, n := range t.GetTops(collection) {
+		if !matchPath(n, paths[0]) {
+			continue
+		}
+		if node.IsCollection(n) && len(paths) > 1 {
+			tops = append(tops, t.matchTops(n.GetPath(), paths[1:])...)
+			continue
+		}
+		tops = append(tops, &top{n, paths[1:]})
+	}
+	return tops
+}
+
+// GetTops returns the sub-collections of collection
+// followed by the storages it directly contains,
+// the empty collection is the root of the tree
+func (t *Tree) GetTops(collection string) []node.Node {
+	collections := make(map[string]*node.CollectionNode)
+	var names []string
+	var storages []node.Node
+	for _, sto := range t.Storages {
+		if sto.Collection == collection {
+			storages = append(storages, sto)
+			continue
+		}
+		rel, ok := subCollection(collection, sto.Collection)
+		if !ok {
+			continue
+		}
+		name := strings.SplitN(rel, node.CollectionSeparator, 2)[0]
+		coll, ok := collections[name]
+		if !ok {
+			path := name
+			if len(collection) > 0 {
+				path = collection + node.CollectionSeparator + name
+			}
+			coll = &node.CollectionNode{Path: path}
+			collections[name] = coll
+			names = append(names, name)
+		}
+		coll.Size += sto.GetSize()
+		coll.IndexedAt = max(coll.IndexedAt, sto.IndexedAt)
+		coll.Storages++
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		return strings.ToLower(names[i]) < strings.ToLower(names[j])
+	})
+	var tops []node.Node
+	for _, name := range names {
+		tops = append(tops, collections[name])
+	}
+	return append(tops, storages...)
+}
+
+// subCollection returns the path of collection relative to parent
+// and true if collection is below parent
+func subCollection(parent string, collection string) (string, bool) {
+	if len(collection) < 1 || collection == parent {
+		return "", false
+	}
+	if len(parent) < 1 {
+		return collection, true
+	}
+	prefix := parent + node.CollectionSeparator
+	if !strings.HasPrefix(collection, prefix) {
+		return "", false
+	}
+	return strings.TrimPrefix(collection, prefix), true
+}
+
+// GetCollections returns all collections, sorted
+func (t *Tree) GetCollections() []string {
+	seen := make(map[string]bool)
+	var collections []string
+	for _, sto := range t.Storages {
+		parts := strings.Split(sto.Collection, node.CollectionSeparator)
+		for i := range parts {
+			coll := strings.Join(parts[:i+1], node.CollectionSeparator)
+			if len(coll) < 1 || seen[coll] {
+				continue
 			}
+			seen[coll] = true
+			collections = append(collections, coll)
 		}
 	}
+	sort.Strings(collections)
+	return collections
+}
 
-	if len(found) < 1 {
-		return tops
+// GetCollectionStorages returns the storages of the
+// collection including the ones of its sub-collections
+func (t *Tree) GetCollectionStorages(collection string) []*node.StorageNode {
+	var storages []*node.StorageNode
+	for _, sto := range t.Storages {
+		if _, ok := subCollection(collection, sto.Collection); ok || sto.Collection == collection {
+			storages = append(storages, sto)
+		}
 	}
-	return found
+	return storages
 }
 
 // GetStorages returns all storage for this tree
@@ -141,8 +307,7 @@ func (t *Tree) processChildren(n node.Node, callback ProcessCallback, indent int
 		return nil
 	}
 
-	children := n.GetSortedDirectChildren()
-	for _, child := range children {
+	for _, child := range t.getChildren(n) {
 		// filter hidden files
 		if strings.HasPrefix(child.GetName(), ".") && !hiddenToo {
 			continue
@@ -166,15 +331,65 @@ func (t *Tree) processChildren(n node.Node, callback ProcessCallback, indent int
 	return retNodes
 }
 
+// getChildren returns the sorted children of a node,
+// the content of a collection for collections
+func (t *Tree) getChildren(n node.Node) []node.Node {
+	if node.IsCollection(n) {
+		return t.GetTops(n.GetPath())
+	}
+	var children []node.Node
+	for _, child := range n.GetSortedDirectChildren() {
+		children = append(children, child)
+	}
+	return children
+}
+
 // GetStorageNode returns the storage for this node
+// or nil for collections
 func (t *Tree) GetStorageNode(n node.Node) *node.StorageNode {
-	if n.GetType() == node.FileTypeStorage {
-		sto := n.(*node.StorageNode)
-		return sto
+	switch typed := n.(type) {
+	case *node.StorageNode:
+		return typed
+	case *node.FileNode:
+		var storages []*node.StorageNode
+		for _, storage := range t.Storages {
+			if storage.ID == typed.StorageID {
+				storages = append(storages, storage)
+			}
+		}
+		if len(storages) == 1 {
+			return storages[0]
+		}
+		// federated catalogs may have storages with the same id
+		for _, storage := range storages {
+			if holds(storage, typed) {
+				return storage
+			}
+		}
+	}
+	return nil
+}
+
+// holds returns true if the node is found under storage
+func holds(storage *node.StorageNode, n *node.FileNode) bool {
+	children := storage.GetSortedDirectChildren()
+	for _, name := range helpers.SplitPath(n.GetPath()) {
+		var next *node.FileNode
+		for _, child := range children {
+			if child.GetName() == name {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			return false
+		}
+		if next == n {
+			return true
+		}
+		children = next.GetSortedDirectChildren()
 	}
-	fnode := n.(*node.FileNode)
-	id := fnode.StorageID
-	return t.GetStorageByID(id)
+	return false
 }
 
 // GetStorageByID returns the storage by id
@@ -208,10 +423,141 @@ func (t *Tree) RemoveStorage(name string) {
 	t.Storages = newStorage
 }
 
+// RenameStorage renames a storage, the ids of the storage and
+// its entries are kept for the indexes of the catalog to stay valid
+func (t *Tree) RenameStorage(storage *node.StorageNode, name string) {
+	storage.Name = name
+	storage.SetDirty(true)
+}
+
+// NewStorageID returns the id derived from the storage name or,
+// if a storage renamed from that name already uses it, a variant
+func (t *Tree) NewStorageID(name string) int {
+	id := node.DeriveStorageID(name)
+	for i := 2; t.GetStorageByID(id) != nil; i++ {
+		id = node.DeriveStorageID(fmt.Sprintf("%s-%d", name, i))
+	}
+	return id
+}
+
+// resetStorageID gives the storage a new id and
+// derives the ids of its entries from it
+func (t *Tree) resetStorageID(storage *node.StorageNode) {
+	storage.ID = t.NewStorageID(storage.GetName())
+	for _, child := range storage.GetSortedDirectChildren() {
+		setStorageID(storage.ID, child)
+	}
+	storage.SetDirty(true)
+}
+
+func setStorageID(storageID int, n *node.FileNode) {
+	n.StorageID = storageID
+	n.ID = node.DeriveFileID(storageID, n.GetPath())
+	for _, child := range n.GetSortedDirectChildren() {
+		setStorageID(storageID, child)
+	}
+}
+
+// MergePolicy how a storage colliding with an existing
+// one (same name or id) is added to a tree
+type MergePolicy string
+
+const (
+	// MergeRename adds the storage under a new name
+	MergeRename MergePolicy = "rename"
+	// MergeSkip keeps the existing storage
+	MergeSkip MergePolicy = "skip"
+	// MergeReplace replaces the storage with the same name
+	MergeReplace MergePolicy = "replace"
+)
+
+// MergePolicies returns the supported merge policies
+func MergePolicies() []string {
+	return []string{string(MergeRename), string(MergeSkip), string(MergeReplace)}
+}
+
+// getCollision returns the storage colliding with storage
+func (t *Tree) getCollision(storage *node.StorageNode) *node.StorageNode {
+	for _, existing := range t.Storages {
+		if existing.GetName() == storage.GetName() || existing.ID == storage.ID {
+			return existing
+		}
+	}
+	return nil
+}
+
+// AddStorage adds a storage to the tree resolving collisions
+// with policy, returns the name the storage was added
+// with or an empty string if it was skipped
+func (t *Tree) AddStorage(storage *node.StorageNode, policy MergePolicy) string {
+	existing := t.getCollision(storage)
+	if existing != nil {
+		switch policy {
+		case MergeSkip:
+			log.Debugf("storage \"%s\" already exists, skipping", storage.GetName())
+			return ""
+		case MergeReplace:
+			log.Debugf("storage \"%s\" already exists, replacing", storage.GetName())
+			t.RemoveStorage(storage.GetName())
+			if t.GetStorageByID(storage.ID) != nil {
+				// another storage, renamed from that name, uses the id
+				t.resetStorageID(storage)
+			}
+		default:
+			name := storage.GetName()
+			for i := 2; t.getCollision(storage) != nil; i++ {
+				t.RenameStorage(storage, fmt.Sprintf("%s-%d", name, i))
+				if t.GetStorageByID(storage.ID) != nil {
+					// the ids must be unique in a catalog
+					t.resetStorageID(storage)
+				}
+			}
+			log.Debugf("storage \"%s\" already exists, renamed to \"%s\"", name, storage.GetName())
+		}
+	}
+	t.Storages = append(t.Storages, storage)
+	return storage.GetName()
+}
+
+// GetOrigin returns the index of the federated catalog the
+// storage comes from, 0 if the tree is not federated
+func (t *Tree) GetOrigin(storage *node.StorageNode) int {
+	return t.origins[storage]
+}
+
+// Federate merges the trees of several catalogs in a single
+// read-only tree, storages are shown as "<catalog>:<storage>"
+// and keep their ids
+func Federate(names []string, trees []*Tree) *Tree {
+	federated, _ := NewTree("")
+	federated.origins = make(map[*node.StorageNode]int)
+	seen := make(map[string]int)
+	for i, t := range trees {
+		name := names[i]
+		seen[name]++
+		if seen[name] > 1 {
+			// catalogs with the same name in different directories
+			name = fmt.Sprintf("%s-%d", name, seen[name])
+			log.Warnf("catalog \"%s\" found multiple times, using \"%s\"", names[i], name)
+		}
+		for _, storage := range t.Storages {
+			// only the name changes, the ids are the ones of the catalog
+			storage.Name = name + FederationSeparator + storage.GetName()
+			federated.Storages = append(federated.Storages, storage)
+			federated.origins[storage] = i
+		}
+		federated.Created = min(federated.Created, t.Created)
+		federated.Updated = max(federated.Updated, t.Updated)
+	}
+	log.Debugf("federated %d catalog(s) with %d storage(s)", len(trees), len(federated.Storages))
+	return federated
+}
+
 // NewTree creates a new tree
 func NewTree(version string) (*Tree, error) {
 	tree := Tree{
 		Version: version,
+		Schema:  SchemaVersion,
 		Created: time.Now().Unix(),
 		Tool:    toolName,
 	}
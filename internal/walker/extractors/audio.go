@@ -0,0 +1,331 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package extractors
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/deadc0de6/gocatcli/internal/log"
+)
+
+const (
+	id3v1Size   = 128
+	flacMagic   = "fLaC"
+	flacInfo    = 0
+	flacComment = 4
+	// max size of a flac metadata block read
+	flacMaxBlock = 1024 * 1024
+)
+
+var (
+	// id3v2 frames (v2.3/v2.4 and v2.2)
+	id3Frames = map[string]string{
+		"TIT2": "title",
+		"TPE1": "artist",
+		"TALB": "album",
+		"TYER": "year",
+		"TDRC": "year",
+		"TCON": "genre",
+		"TRCK": "track",
+		"TT2":  "title",
+		"TP1":  "artist",
+		"TAL":  "album",
+		"TYE":  "year",
+		"TCO":  "genre",
+		"TRK":  "track",
+	}
+
+	// vorbis comments
+	vorbisFields = map[string]string{
+		"TITLE":       "title",
+		"ARTIST":      "artist",
+		"ALBUM":       "album",
+		"DATE":        "year",
+		"GENRE":       "genre",
+		"TRACKNUMBER": "track",
+	}
+)
+
+// audioExtractor extracts the tags of mp3 (id3)
+// and flac (vorbis comments) files
+type audioExtractor struct{}
+
+// Name returns the extractor name
+func (e *audioExtractor) Name() string {
+	return "audio"
+}
+
+// Handles returns true for mp3 and flac
+func (e *audioExtractor) Handles(mime string) bool {
+	return mime == "audio/mpeg" || mime == "audio/x-flac"
+}
+
+// Extract returns the audio tags
+func (e *audioExtractor) Extract(path string) (map[string]string, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := fd.Close()
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+
+	magic := make([]byte, 4)
+	_, err = io.ReadFull(fd, magic)
+	if err != nil {
+		return nil, err
+	}
+	_, err = fd.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata map[string]string
+	if string(magic) == flacMagic {
+		metadata, err = readFLAC(fd)
+	} else {
+		metadata, err = readID3v2(fd)
+		if len(metadata) < 1 {
+			metadata, err = readID3v1(fd)
+		}
+	}
+	if year, ok := metadata["year"]; ok && len(year) > 4 {
+		// only keep the year of dates
+		metadata["year"] = year[:4]
+	}
+	return metadata, err
+}
+
+// syncsafe decodes a 7 bits per byte integer
+func syncsafe(b []byte) int {
+	var value int
+	for _, c := range b {
+		value = value<<7 | int(c&0x7F)
+	}
+	return value
+}
+
+// id3Text decodes an id3v2 text frame
+func id3Text(frame []byte) string {
+	if len(frame) < 1 {
+		return ""
+	}
+	encoding, text := frame[0], frame[1:]
+	switch encoding {
+	case 0:
+		// iso-8859-1
+		runes := make([]rune, len(text))
+		for i, c := range text {
+			runes[i] = rune(c)
+		}
+		return strings.TrimRight(string(runes), "\x00")
+	case 1, 2:
+		// utf-16 with bom or big endian
+		var order binary.ByteOrder = binary.BigEndian
+		if encoding == 1 && len(text) >= 2 {
+			if text[0] == 0xFF && text[1] == 0xFE {
+				order = binary.LittleEndian
+			}
+			text = text[2:]
+		}
+		units := make([]uint16, 0, len(text)/2)
+		for i := 0; i+1 < len(text); i += 2 {
+			units = append(units, order.Uint16(text[i:]))
+		}
+		return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+	}
+	// utf-8
+	return strings.TrimRight(string(text), "\x00")
+}
+
+// readID3v2 reads the id3v2 tag at the start of the file
+func readID3v2(r io.Reader) (map[string]string, error) {
+	header := make([]byte, 10)
+	_, err := io.ReadFull(r, header)
+	if err != nil {
+		return nil, err
+	}
+	if string(header[:3]) != "ID3" {
+		return nil, nil
+	}
+	version := header[3]
+	flags := header[5]
+	size := syncsafe(header[6:10])
+	data, err := io.ReadAll(io.LimitReader(r, int64(min(size, headLimit))))
+	if err != nil {
+		return nil, err
+	}
+
+	pos := 0
+	if flags&0x40 != 0 && len(data) >= 4 {
+		// skip the extended header
+		if version == 4 {
+			pos = syncsafe(data[:4])
+		} else {
+			pos = int(binary.BigEndian.Uint32(data)) + 4
+		}
+	}
+
+	idSize, headerSize := 4, 10
+	if version == 2 {
+		idSize, headerSize = 3, 6
+	}
+
+	metadata := make(map[string]string)
+	for pos+headerSize <= len(data) {
+		id := string(data[pos : pos+idSize])
+		if id[0] == 0 {
+			// padding
+			break
+		}
+		var frameSize int
+		switch version {
+		case 2:
+			frameSize = int(data[pos+3])<<16 | int(data[pos+4])<<8 | int(data[pos+5])
+		case 4:
+			frameSize = syncsafe(data[pos+4 : pos+8])
+		default:
+			frameSize = int(binary.BigEndian.Uint32(data[pos+4:]))
+		}
+		start := pos + headerSize
+		end := start + frameSize
+		if frameSize < 0 || end > len(data) {
+			break
+		}
+		if key, ok := id3Frames[id]; ok {
+			metadata[key] = id3Text(data[start:end])
+		}
+		pos = end
+	}
+	return metadata, nil
+}
+
+// readID3v1 reads the id3v1 tag at the end of the file
+func readID3v1(r io.ReadSeeker) (map[string]string, error) {
+	_, err := r.Seek(-id3v1Size, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	tag := make([]byte, id3v1Size)
+	_, err = io.ReadFull(r, tag)
+	if err != nil {
+		return nil, err
+	}
+	if string(tag[:3]) != "TAG" {
+		return nil, nil
+	}
+	field := func(b []byte) string {
+		return strings.TrimRight(string(bytes.TrimRight(b, "\x00")), " ")
+	}
+	return map[string]string{
+		"title":  field(tag[3:33]),
+		"artist": field(tag[33:63]),
+		"album":  field(tag[63:93]),
+		"year":   field(tag[93:97]),
+	}, nil
+}
+
+// readFLAC reads the flac stream info and vorbis comments
+func readFLAC(r io.ReadSeeker) (map[string]string, error) {
+	_, err := r.Seek(int64(len(flacMagic)), io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := make(map[string]string)
+	header := make([]byte, 4)
+	for {
+		_, err = io.ReadFull(r, header)
+		if err != nil {
+			return metadata, err
+		}
+		last := header[0]&0x80 != 0
+		typ := header[0] & 0x7F
+		size := int64(header[1])<<16 | int64(header[2])<<8 | int64(header[3])
+
+		if (typ == flacInfo || typ == flacComment) && size <= flacMaxBlock {
+			block := make([]byte, size)
+			_, err = io.ReadFull(r, block)
+			if err != nil {
+				return metadata, err
+			}
+			if typ == flacInfo {
+				readFLACInfo(block, metadata)
+			} else {
+				readVorbisComments(block, metadata)
+			}
+		} else {
+			_, err = r.Seek(size, io.SeekCurrent)
+			if err != nil {
+				return metadata, err
+			}
+		}
+		if last {
+			return metadata, nil
+		}
+	}
+}
+
+// readFLACInfo reads the duration from the stream info block
+func readFLACInfo(block []byte, metadata map[string]string) {
+	if len(block) < 18 {
+		return
+	}
+	rate := uint64(block[10])<<12 | uint64(block[11])<<4 | uint64(block[12])>>4
+	samples := uint64(block[13]&0x0F)<<32 | uint64(binary.BigEndian.Uint32(block[14:18]))
+	if rate > 0 && samples > 0 {
+		metadata["duration"] = formatSeconds(float64(samples) / float64(rate))
+	}
+}
+
+// readVorbisComments reads the "KEY=value" comments
+func readVorbisComments(block []byte, metadata map[string]string) {
+	// next returns the next length prefixed string
+	pos := 0
+	next := func() (string, error) {
+		if pos+4 > len(block) {
+			return "", fmt.Errorf("vorbis comment out of range")
+		}
+		size := int(binary.LittleEndian.Uint32(block[pos:]))
+		pos += 4
+		if size < 0 || pos+size > len(block) {
+			return "", fmt.Errorf("vorbis comment out of range")
+		}
+		value := string(block[pos : pos+size])
+		pos += size
+		return value, nil
+	}
+
+	// vendor
+	_, err := next()
+	if err != nil || pos+4 > len(block) {
+		return
+	}
+	cnt := int(binary.LittleEndian.Uint32(block[pos:]))
+	pos += 4
+	for i := 0; i < cnt; i++ {
+		comment, err := next()
+		if err != nil {
+			return
+		}
+		key, value, ok := strings.Cut(comment, "=")
+		if !ok {
+			continue
+		}
+		if field, ok := vorbisFields[strings.ToUpper(key)]; ok {
+			metadata[field] = value
+		}
+	}
+}
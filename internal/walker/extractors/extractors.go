@@ -0,0 +1,106 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package extractors
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+
+	"github.com/deadc0de6/gocatcli/internal/log"
+)
+
+const (
+	// max number of bytes read to find the metadata
+	headLimit = 1024 * 1024
+)
+
+// Extractor extracts metadata from the files of some mime types
+type Extractor interface {
+	// Name returns the extractor name
+	Name() string
+	// Handles returns true if the mime type is supported
+	Handles(mime string) bool
+	// Extract returns the metadata of the file at path
+	Extract(path string) (map[string]string, error)
+}
+
+var (
+	// extractors run in their registration order
+	registry []Extractor
+)
+
+func init() {
+	Register(&imageExtractor{})
+	Register(&audioExtractor{})
+	Register(&videoExtractor{})
+	Register(&pdfExtractor{})
+}
+
+// Register adds an extractor
+func Register(e Extractor) {
+	registry = append(registry, e)
+}
+
+// Extract runs the extractors supporting the mime type on
+// the file at path, returns nil if nothing was found
+func Extract(path string, mime string) map[string]string {
+	var metadata map[string]string
+	for _, e := range registry {
+		if !e.Handles(mime) {
+			continue
+		}
+		found, err := e.Extract(path)
+		if err != nil {
+			log.Debugf("%s extractor failed for \"%s\": %v", e.Name(), path, err)
+		}
+		for key, value := range found {
+			value = clean(value)
+			if len(value) < 1 {
+				continue
+			}
+			if metadata == nil {
+				metadata = make(map[string]string)
+			}
+			metadata[key] = value
+		}
+	}
+	log.Debugf("\"%s\" metadata: %v", path, metadata)
+	return metadata
+}
+
+// clean removes the control characters and the surrounding spaces
+func clean(value string) string {
+	value = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, value)
+	return strings.TrimSpace(value)
+}
+
+// readHead returns up to limit bytes from the start of the file
+func readHead(path string, limit int64) ([]byte, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := fd.Close()
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+	return io.ReadAll(io.LimitReader(fd, limit))
+}
+
+// formatSeconds formats a duration in seconds
+func formatSeconds(seconds float64) string {
+	return fmt.Sprintf("%d", int64(seconds+0.5))
+}
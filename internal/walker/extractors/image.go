@@ -0,0 +1,333 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package extractors
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	_ "image/gif"  // gif dimensions
+	_ "image/jpeg" // jpeg dimensions
+	_ "image/png"  // png dimensions
+	"os"
+	"strings"
+
+	"github.com/deadc0de6/gocatcli/internal/log"
+)
+
+const (
+	// tiff/exif tags
+	tagWidth        = 0x0100
+	tagHeight       = 0x0101
+	tagMake         = 0x010F
+	tagModel        = 0x0110
+	tagDateTime     = 0x0132
+	tagExifIFD      = 0x8769
+	tagGPSIFD       = 0x8825
+	tagDateOriginal = 0x9003
+	tagPixelXDim    = 0xA002
+	tagPixelYDim    = 0xA003
+	tagGPSLatRef    = 0x0001
+	tagGPSLat       = 0x0002
+	tagGPSLonRef    = 0x0003
+	tagGPSLon       = 0x0004
+	exifHeader      = "Exif\x00\x00"
+	jpegMarkerStart = 0xFF
+	jpegMarkerSOS   = 0xDA
+	jpegMarkerEOI   = 0xD9
+	jpegMarkerAPP1  = 0xE1
+	tiffMaxEntries  = 1024
+)
+
+var (
+	// size in bytes of the tiff field types
+	tiffTypeSizes = map[uint16]uint32{
+		1:  1, // byte
+		2:  1, // ascii
+		3:  2, // short
+		4:  4, // long
+		5:  8, // rational
+		7:  1, // undefined
+		9:  4, // slong
+		10: 8, // srational
+	}
+)
+
+// imageExtractor extracts the dimensions and the exif
+// fields (camera, capture date and gps position) of images
+type imageExtractor struct{}
+
+// Name returns the extractor name
+func (e *imageExtractor) Name() string {
+	return "image"
+}
+
+// Handles returns true for images
+func (e *imageExtractor) Handles(mime string) bool {
+	switch mime {
+	case "image/jpeg", "image/png", "image/gif", "image/tiff", "image/x-canon-cr2":
+		return true
+	}
+	return false
+}
+
+// Extract returns the image metadata
+func (e *imageExtractor) Extract(path string) (map[string]string, error) {
+	metadata := make(map[string]string)
+
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	cfg, _, err := image.DecodeConfig(fd)
+	cerr := fd.Close()
+	if cerr != nil {
+		log.Error(cerr)
+	}
+	if err == nil {
+		metadata["width"] = fmt.Sprintf("%d", cfg.Width)
+		metadata["height"] = fmt.Sprintf("%d", cfg.Height)
+	}
+
+	head, err := readHead(path, headLimit)
+	if err != nil {
+		return metadata, err
+	}
+	tiff := findTIFF(head)
+	if tiff == nil {
+		return metadata, nil
+	}
+	exif, err := parseTIFF(tiff)
+	for key, value := range exif {
+		if _, ok := metadata[key]; ok {
+			// the decoded dimensions prevail
+			continue
+		}
+		metadata[key] = value
+	}
+	return metadata, err
+}
+
+// findTIFF returns the tiff structure holding the exif
+// fields of a jpeg or a tiff file
+func findTIFF(data []byte) []byte {
+	if bytes.HasPrefix(data, []byte("II*\x00")) || bytes.HasPrefix(data, []byte("MM\x00*")) {
+		return data
+	}
+	if len(data) < 4 || data[0] != jpegMarkerStart || data[1] != 0xD8 {
+		return nil
+	}
+
+	// walk the jpeg segments up to the image data
+	pos := 2
+	for pos+4 <= len(data) && data[pos] == jpegMarkerStart {
+		marker := data[pos+1]
+		if marker == jpegMarkerSOS || marker == jpegMarkerEOI {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		end := pos + 2 + length
+		if length < 2 || end > len(data) {
+			break
+		}
+		segment := data[pos+4 : end]
+		if marker == jpegMarkerAPP1 && bytes.HasPrefix(segment, []byte(exifHeader)) {
+			return segment[len(exifHeader):]
+		}
+		pos = end
+	}
+	return nil
+}
+
+// tiffEntry an entry of a tiff ifd
+type tiffEntry struct {
+	typ   uint16
+	count uint32
+	value []byte
+}
+
+// tiffReader reads a tiff structure
+type tiffReader struct {
+	data  []byte
+	order binary.ByteOrder
+}
+
+// ifd returns the entries of the ifd at offset
+func (r *tiffReader) ifd(offset uint32) (map[uint16]*tiffEntry, error) {
+	if uint64(offset)+2 > uint64(len(r.data)) {
+		return nil, fmt.Errorf("ifd out of range")
+	}
+	cnt := uint32(r.order.Uint16(r.data[offset:]))
+	if cnt > tiffMaxEntries {
+		return nil, fmt.Errorf("too many ifd entries (%d)", cnt)
+	}
+
+	entries := make(map[uint16]*tiffEntry)
+	for i := uint32(0); i < cnt; i++ {
+		pos := uint64(offset) + 2 + uint64(i)*12
+		if pos+12 > uint64(len(r.data)) {
+			return entries, fmt.Errorf("ifd entry out of range")
+		}
+		raw := r.data[pos : pos+12]
+		e := &tiffEntry{
+			typ:   r.order.Uint16(raw[2:]),
+			count: r.order.Uint32(raw[4:]),
+		}
+		size, ok := tiffTypeSizes[e.typ]
+		if !ok {
+			continue
+		}
+		total := uint64(size) * uint64(e.count)
+		if total <= 4 {
+			e.value = raw[8 : 8+total]
+		} else {
+			start := uint64(r.order.Uint32(raw[8:]))
+			if start+total > uint64(len(r.data)) {
+				continue
+			}
+			e.value = r.data[start : start+total]
+		}
+		entries[r.order.Uint16(raw)] = e
+	}
+	return entries, nil
+}
+
+// ascii returns the string value of an entry
+func (r *tiffReader) ascii(e *tiffEntry) string {
+	if e == nil || e.typ != 2 {
+		return ""
+	}
+	return strings.TrimRight(string(e.value), "\x00 ")
+}
+
+// uint returns the first integer value of an entry
+func (r *tiffReader) uint(e *tiffEntry) (uint32, bool) {
+	if e == nil || e.count < 1 {
+		return 0, false
+	}
+	switch e.typ {
+	case 3:
+		return uint32(r.order.Uint16(e.value)), true
+	case 4:
+		return r.order.Uint32(e.value), true
+	}
+	return 0, false
+}
+
+// rationals returns the rational values of an entry
+func (r *tiffReader) rationals(e *tiffEntry) []float64 {
+	if e == nil || e.typ != 5 {
+		return nil
+	}
+	var values []float64
+	for i := 0; i+8 <= len(e.value); i += 8 {
+		num := r.order.Uint32(e.value[i:])
+		den := r.order.Uint32(e.value[i+4:])
+		if den == 0 {
+			return nil
+		}
+		values = append(values, float64(num)/float64(den))
+	}
+	return values
+}
+
+// coordinate returns the decimal gps coordinate
+func (r *tiffReader) coordinate(e *tiffEntry, ref string) (float64, bool) {
+	dms := r.rationals(e)
+	if len(dms) != 3 {
+		return 0, false
+	}
+	value := dms[0] + dms[1]/60 + dms[2]/3600
+	if ref == "S" || ref == "W" {
+		value = -value
+	}
+	return value, true
+}
+
+// exifDate converts "2006:01:02 15:04:05" to "2006-01-02 15:04:05"
+func exifDate(value string) string {
+	date, clock, ok := strings.Cut(value, " ")
+	if !ok {
+		return value
+	}
+	return strings.ReplaceAll(date, ":", "-") + " " + clock
+}
+
+// parseTIFF returns the exif fields of a tiff structure
+func parseTIFF(data []byte) (map[string]string, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("tiff header too short")
+	}
+	r := &tiffReader{data: data}
+	switch string(data[:2]) {
+	case "II":
+		r.order = binary.LittleEndian
+	case "MM":
+		r.order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("bad tiff byte order")
+	}
+	if r.order.Uint16(data[2:]) != 42 {
+		return nil, fmt.Errorf("bad tiff magic")
+	}
+
+	ifd0, err := r.ifd(r.order.Uint32(data[4:]))
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := make(map[string]string)
+	if width, ok := r.uint(ifd0[tagWidth]); ok {
+		metadata["width"] = fmt.Sprintf("%d", width)
+	}
+	if height, ok := r.uint(ifd0[tagHeight]); ok {
+		metadata["height"] = fmt.Sprintf("%d", height)
+	}
+
+	// camera
+	maker := r.ascii(ifd0[tagMake])
+	model := r.ascii(ifd0[tagModel])
+	camera := model
+	if !strings.HasPrefix(model, maker) {
+		camera = maker + " " + model
+	}
+	metadata["camera"] = camera
+
+	// capture date
+	if date := r.ascii(ifd0[tagDateTime]); len(date) > 0 {
+		metadata["taken"] = exifDate(date)
+	}
+	if offset, ok := r.uint(ifd0[tagExifIFD]); ok {
+		exif, err := r.ifd(offset)
+		if err != nil {
+			return metadata, err
+		}
+		if date := r.ascii(exif[tagDateOriginal]); len(date) > 0 {
+			metadata["taken"] = exifDate(date)
+		}
+		if width, ok := r.uint(exif[tagPixelXDim]); ok {
+			metadata["width"] = fmt.Sprintf("%d", width)
+		}
+		if height, ok := r.uint(exif[tagPixelYDim]); ok {
+			metadata["height"] = fmt.Sprintf("%d", height)
+		}
+	}
+
+	// gps position
+	if offset, ok := r.uint(ifd0[tagGPSIFD]); ok {
+		gps, err := r.ifd(offset)
+		if err != nil {
+			return metadata, err
+		}
+		lat, latOk := r.coordinate(gps[tagGPSLat], r.ascii(gps[tagGPSLatRef]))
+		lon, lonOk := r.coordinate(gps[tagGPSLon], r.ascii(gps[tagGPSLonRef]))
+		if latOk && lonOk {
+			metadata["gps"] = fmt.Sprintf("%.6f,%.6f", lat, lon)
+		}
+	}
+	return metadata, nil
+}
@@ -0,0 +1,224 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package extractors
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	// max size of the pdf files parsed
+	pdfMaxSize = 64 * 1024 * 1024
+	// max size of an inflated object stream
+	pdfMaxStream = 16 * 1024 * 1024
+)
+
+var (
+	pdfObjStmRe = regexp.MustCompile(`/Type\s*/ObjStm\b[^>]*>>\s*stream\r?\n`)
+	pdfPagesRe  = regexp.MustCompile(`/Type\s*/Pages\b`)
+	pdfCountRe  = regexp.MustCompile(`/Count\s+(\d+)`)
+	pdfPageRe   = regexp.MustCompile(`/Type\s*/Page\b`)
+	pdfTitleRe  = regexp.MustCompile(`/Title\s*([(<])`)
+	pdfAuthorRe = regexp.MustCompile(`/Author\s*([(<])`)
+)
+
+// pdfExtractor extracts the number of pages, the
+// title and the author of pdf files
+type pdfExtractor struct{}
+
+// Name returns the extractor name
+func (e *pdfExtractor) Name() string {
+	return "pdf"
+}
+
+// Handles returns true for pdf
+func (e *pdfExtractor) Handles(mime string) bool {
+	return mime == "application/pdf"
+}
+
+// Extract returns the pdf metadata
+func (e *pdfExtractor) Extract(path string) (map[string]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() > pdfMaxSize {
+		return nil, fmt.Errorf("too large (%d)", info.Size())
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// objects may be compressed in object streams
+	data = append(data, inflateObjectStreams(data)...)
+
+	metadata := make(map[string]string)
+	if pages := pdfPages(data); pages > 0 {
+		metadata["pages"] = fmt.Sprintf("%d", pages)
+	}
+	if title, ok := pdfString(data, pdfTitleRe); ok {
+		metadata["title"] = title
+	}
+	if author, ok := pdfString(data, pdfAuthorRe); ok {
+		metadata["author"] = author
+	}
+	return metadata, nil
+}
+
+// inflateObjectStreams returns the content of the object streams
+func inflateObjectStreams(data []byte) []byte {
+	var out []byte
+	for _, loc := range pdfObjStmRe.FindAllIndex(data, -1) {
+		r, err := zlib.NewReader(bytes.NewReader(data[loc[1]:]))
+		if err != nil {
+			continue
+		}
+		content, _ := io.ReadAll(io.LimitReader(r, pdfMaxStream))
+		_ = r.Close()
+		out = append(out, '\n')
+		out = append(out, content...)
+	}
+	return out
+}
+
+// pdfPages returns the number of pages
+func pdfPages(data []byte) int {
+	pages := 0
+	for _, loc := range pdfPagesRe.FindAllIndex(data, -1) {
+		// the count is in the same dictionary
+		start := bytes.LastIndex(data[:loc[0]], []byte("<<"))
+		end := bytes.Index(data[loc[1]:], []byte(">>"))
+		if start < 0 || end < 0 {
+			continue
+		}
+		dict := data[start : loc[1]+end]
+		for _, m := range pdfCountRe.FindAllSubmatch(dict, -1) {
+			cnt, err := strconv.Atoi(string(m[1]))
+			if err == nil {
+				pages = max(pages, cnt)
+			}
+		}
+	}
+	if pages > 0 {
+		return pages
+	}
+	// fallback on counting the page objects
+	return len(pdfPageRe.FindAll(data, -1))
+}
+
+// pdfString returns the first string value of the key matched by re
+func pdfString(data []byte, re *regexp.Regexp) (string, bool) {
+	loc := re.FindSubmatchIndex(data)
+	if loc == nil {
+		return "", false
+	}
+	var raw []byte
+	if data[loc[2]] == '(' {
+		raw, _ = pdfLiteral(data[loc[3]:])
+	} else {
+		raw, _ = pdfHex(data[loc[3]:])
+	}
+	return pdfDecode(raw), true
+}
+
+// pdfLiteral decodes a "(...)" string starting after
+// the opening parenthesis, returns the bytes consumed
+func pdfLiteral(data []byte) ([]byte, int) {
+	var out []byte
+	depth := 0
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth == 0 {
+				return out, i + 1
+			}
+			depth--
+		case '\\':
+			i++
+			if i >= len(data) {
+				return out, i
+			}
+			c = data[i]
+			switch c {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			case 'b':
+				c = '\b'
+			case 'f':
+				c = '\f'
+			case '\r', '\n':
+				// line continuation
+				continue
+			default:
+				if c >= '0' && c <= '7' {
+					// up to 3 octal digits
+					value := int(c - '0')
+					for j := 0; j < 2 && i+1 < len(data) && data[i+1] >= '0' && data[i+1] <= '7'; j++ {
+						i++
+						value = value*8 + int(data[i]-'0')
+					}
+					c = byte(value)
+				}
+			}
+		}
+		out = append(out, c)
+	}
+	return out, len(data)
+}
+
+// pdfHex decodes a "<...>" string starting after
+// the opening bracket, returns the bytes consumed
+func pdfHex(data []byte) ([]byte, int) {
+	end := bytes.IndexByte(data, '>')
+	if end < 0 {
+		return nil, len(data)
+	}
+	digits := strings.Join(strings.Fields(string(data[:end])), "")
+	if len(digits)%2 != 0 {
+		digits += "0"
+	}
+	var out []byte
+	for i := 0; i+1 < len(digits); i += 2 {
+		value, err := strconv.ParseUint(digits[i:i+2], 16, 8)
+		if err != nil {
+			return out, end + 1
+		}
+		out = append(out, byte(value))
+	}
+	return out, end + 1
+}
+
+// pdfDecode decodes utf-16be (with a bom) or latin-1 strings
+func pdfDecode(raw []byte) string {
+	if len(raw) >= 2 && raw[0] == 0xFE && raw[1] == 0xFF {
+		units := make([]uint16, 0, len(raw)/2)
+		for i := 2; i+1 < len(raw); i += 2 {
+			units = append(units, uint16(raw[i])<<8|uint16(raw[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+	runes := make([]rune, len(raw))
+	for i, c := range raw {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
@@ -0,0 +1,245 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package extractors
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/deadc0de6/gocatcli/internal/log"
+)
+
+const (
+	// number of bytes sniffed to detect text files
+	sniffSize = 8 * 1024
+)
+
+var (
+	// parts of the office documents holding their text
+	officeParts = map[string][]string{
+		".docx": {"word/document.xml"},
+		".xlsx": {"xl/sharedStrings.xml"},
+		".pptx": {"ppt/slides/slide*.xml"},
+		".odt":  {"content.xml"},
+		".ods":  {"content.xml"},
+		".odp":  {"content.xml"},
+	}
+
+	// xml elements ending a line of text
+	officeLineEnds = map[string]bool{
+		"p":  true, // paragraphs (docx, pptx, odf)
+		"h":  true, // headings (odf)
+		"si": true, // shared strings (xlsx)
+		"tr": true, // table rows (docx)
+	}
+
+	pdfStreamRe = regexp.MustCompile(`(?s)<<((?:[^<>]|<<[^<>]*>>|<[^<>]*>)*)>>\s*stream\r?\n`)
+)
+
+// ExtractText returns the text content of the file at path:
+// text files, pdf and office documents. Files larger than limit
+// are skipped and the text is truncated to limit bytes
+func ExtractText(path string, mime string, limit int64) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		log.Debugf("cannot extract text from \"%s\": %v", path, err)
+		return ""
+	}
+	if info.Size() > limit || info.Size() < 1 {
+		return ""
+	}
+
+	var text string
+	ext := strings.ToLower(filepath.Ext(path))
+	switch {
+	case mime == "application/pdf":
+		text, err = pdfText(path)
+	case len(officeParts[ext]) > 0:
+		text, err = officeText(path, officeParts[ext])
+	case len(mime) < 1 || strings.HasPrefix(mime, "text/"):
+		text, err = plainText(path)
+	}
+	if err != nil {
+		log.Debugf("cannot extract text from \"%s\": %v", path, err)
+		return ""
+	}
+
+	if int64(len(text)) > limit {
+		text = text[:limit]
+	}
+	return strings.ToValidUTF8(text, "")
+}
+
+// plainText returns the content of text files
+func plainText(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	head := data[:min(len(data), sniffSize)]
+	if bytes.IndexByte(head, 0) >= 0 {
+		// binary
+		return "", nil
+	}
+	if !utf8.Valid(head) && !utf8.Valid(head[:max(0, len(head)-utf8.UTFMax)]) {
+		// not utf-8 (may be cut in the middle of a rune)
+		return "", nil
+	}
+	return string(data), nil
+}
+
+// officeText returns the text of the xml parts of an office document
+func officeText(path string, parts []string) (string, error) {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		err := r.Close()
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+
+	var sb strings.Builder
+	for _, f := range r.File {
+		for _, part := range parts {
+			matched, _ := filepath.Match(part, f.Name)
+			if !matched {
+				continue
+			}
+			fd, err := f.Open()
+			if err != nil {
+				return sb.String(), err
+			}
+			err = xmlText(fd, &sb)
+			cerr := fd.Close()
+			if err != nil {
+				return sb.String(), err
+			}
+			if cerr != nil {
+				log.Error(cerr)
+			}
+		}
+	}
+	return sb.String(), nil
+}
+
+// xmlText writes the character data of a xml document
+func xmlText(r io.Reader, sb *strings.Builder) error {
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.CharData:
+			sb.Write(t)
+		case xml.EndElement:
+			if officeLineEnds[t.Name.Local] {
+				sb.WriteString("\n")
+			}
+		}
+	}
+}
+
+// pdfText returns the text shown by the content streams of a pdf
+func pdfText(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.Size() > pdfMaxSize {
+		return "", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	for _, loc := range pdfStreamRe.FindAllSubmatchIndex(data, -1) {
+		dict := data[loc[2]:loc[3]]
+		if bytes.Contains(dict, []byte("/Image")) || bytes.Contains(dict, []byte("/ObjStm")) {
+			continue
+		}
+		start := loc[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			continue
+		}
+		stream := data[start : start+end]
+		if bytes.Contains(dict, []byte("/FlateDecode")) {
+			r, err := zlib.NewReader(bytes.NewReader(stream))
+			if err != nil {
+				continue
+			}
+			stream, _ = io.ReadAll(io.LimitReader(r, pdfMaxStream))
+			_ = r.Close()
+		}
+		pdfShownText(stream, &sb)
+	}
+	return sb.String(), nil
+}
+
+// pdfShownText writes the strings shown by the text
+// operators (Tj, TJ, ' and ") of a content stream
+func pdfShownText(stream []byte, sb *strings.Builder) {
+	var pending []string
+	for i := 0; i < len(stream); i++ {
+		c := stream[i]
+		switch {
+		case c == '(':
+			raw, n := pdfLiteral(stream[i+1:])
+			pending = append(pending, pdfDecode(raw))
+			i += n
+		case c == '<' && i+1 < len(stream) && stream[i+1] == '<':
+			i++
+		case c == '<':
+			raw, n := pdfHex(stream[i+1:])
+			pending = append(pending, pdfDecode(raw))
+			i += n
+		case c == '%':
+			// comment
+			for i < len(stream) && stream[i] != '\n' && stream[i] != '\r' {
+				i++
+			}
+		case c == '/':
+			// name
+			for i+1 < len(stream) && !bytes.ContainsRune([]byte(" \t\r\n/[]()<>"), rune(stream[i+1])) {
+				i++
+			}
+		case c == '\'' || c == '"':
+			sb.WriteString("\n" + strings.Join(pending, ""))
+			pending = nil
+		case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '*':
+			j := i
+			for j < len(stream) && ((stream[j] >= 'a' && stream[j] <= 'z') || (stream[j] >= 'A' && stream[j] <= 'Z') || stream[j] == '*') {
+				j++
+			}
+			switch string(stream[i:j]) {
+			case "Tj", "TJ":
+				sb.WriteString(strings.Join(pending, ""))
+			case "Td", "TD", "T*", "ET":
+				sb.WriteString("\n")
+			}
+			pending = nil
+			i = j - 1
+		}
+	}
+}
@@ -0,0 +1,368 @@
+/*
+author: deadc0de6 (https://github.com/deadc0de6)
+Copyright (c) 2024, deadc0de6
+*/
+
+package extractors
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/deadc0de6/gocatcli/internal/log"
+)
+
+const (
+	// max size of the mp4 "moov" atom read
+	moovLimit = 32 * 1024 * 1024
+	// ebml element ids
+	ebmlSegment       = 0x18538067
+	ebmlInfo          = 0x1549A966
+	ebmlTimecodeScale = 0x2AD7B1
+	ebmlDuration      = 0x4489
+	ebmlTracks        = 0x1654AE6B
+	ebmlTrackEntry    = 0xAE
+	ebmlVideo         = 0xE0
+	ebmlPixelWidth    = 0xB0
+	ebmlPixelHeight   = 0xBA
+	ebmlCluster       = 0x1F43B675
+	ebmlMagic         = "\x1A\x45\xDF\xA3"
+)
+
+// videoExtractor extracts the duration and the
+// dimensions of mp4/mov and matroska/webm files
+type videoExtractor struct{}
+
+// Name returns the extractor name
+func (e *videoExtractor) Name() string {
+	return "video"
+}
+
+// Handles returns true for mp4, mov, m4a, mkv and webm
+func (e *videoExtractor) Handles(mime string) bool {
+	switch mime {
+	case "video/mp4", "video/quicktime", "audio/m4a", "video/x-matroska", "video/webm":
+		return true
+	}
+	return false
+}
+
+// Extract returns the video metadata
+func (e *videoExtractor) Extract(path string) (map[string]string, error) {
+	fd, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := fd.Close()
+		if err != nil {
+			log.Error(err)
+		}
+	}()
+
+	magic := make([]byte, 4)
+	_, err = io.ReadFull(fd, magic)
+	if err != nil {
+		return nil, err
+	}
+	_, err = fd.Seek(0, io.SeekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	if string(magic) == ebmlMagic {
+		head, err := io.ReadAll(io.LimitReader(fd, headLimit))
+		if err != nil {
+			return nil, err
+		}
+		return readMatroska(head), nil
+	}
+	return readMP4(fd)
+}
+
+// findAtom seeks over the top-level atoms and returns
+// the payload of the first atom of this type
+func findAtom(r io.ReadSeeker, typ string) ([]byte, error) {
+	header := make([]byte, 8)
+	for {
+		_, err := io.ReadFull(r, header)
+		if err != nil {
+			return nil, err
+		}
+		size := uint64(binary.BigEndian.Uint32(header))
+		headerSize := uint64(8)
+		switch size {
+		case 0:
+			// up to the end of the file
+			cur, err := r.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return nil, err
+			}
+			end, err := r.Seek(0, io.SeekEnd)
+			if err != nil {
+				return nil, err
+			}
+			_, err = r.Seek(cur, io.SeekStart)
+			if err != nil {
+				return nil, err
+			}
+			size = uint64(end-cur) + headerSize
+		case 1:
+			// 64 bits size
+			large := make([]byte, 8)
+			_, err = io.ReadFull(r, large)
+			if err != nil {
+				return nil, err
+			}
+			size = binary.BigEndian.Uint64(large)
+			headerSize += 8
+		}
+		if size < headerSize {
+			return nil, fmt.Errorf("bad atom size")
+		}
+		payload := size - headerSize
+
+		if string(header[4:8]) == typ {
+			if payload > moovLimit {
+				return nil, fmt.Errorf("atom %s too large (%d)", typ, payload)
+			}
+			data := make([]byte, payload)
+			_, err = io.ReadFull(r, data)
+			return data, err
+		}
+		if payload > math.MaxInt64 {
+			return nil, fmt.Errorf("bad atom size")
+		}
+		_, err = r.Seek(int64(payload), io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// walkAtoms calls fn for each atom in data
+func walkAtoms(data []byte, fn func(typ string, payload []byte)) {
+	pos := 0
+	for pos+8 <= len(data) {
+		size := int(binary.BigEndian.Uint32(data[pos:]))
+		typ := string(data[pos+4 : pos+8])
+		if size < 8 || pos+size > len(data) {
+			return
+		}
+		fn(typ, data[pos+8:pos+size])
+		pos += size
+	}
+}
+
+// readMP4 reads the "moov" atom of mp4/mov files
+func readMP4(r io.ReadSeeker) (map[string]string, error) {
+	moov, err := findAtom(r, "moov")
+	if err != nil {
+		return nil, err
+	}
+
+	metadata := make(map[string]string)
+	var width, height uint32
+	walkAtoms(moov, func(typ string, payload []byte) {
+		switch typ {
+		case "mvhd":
+			readMVHD(payload, metadata)
+		case "trak":
+			walkAtoms(payload, func(typ string, payload []byte) {
+				if typ != "tkhd" {
+					return
+				}
+				w, h := readTKHD(payload)
+				width = max(width, w)
+				height = max(height, h)
+			})
+		}
+	})
+	if width > 0 && height > 0 {
+		metadata["width"] = fmt.Sprintf("%d", width)
+		metadata["height"] = fmt.Sprintf("%d", height)
+	}
+	return metadata, nil
+}
+
+// readMVHD reads the duration from the movie header
+func readMVHD(payload []byte, metadata map[string]string) {
+	if len(payload) < 1 {
+		return
+	}
+	var scale, duration uint64
+	if payload[0] == 1 {
+		if len(payload) < 32 {
+			return
+		}
+		scale = uint64(binary.BigEndian.Uint32(payload[20:]))
+		duration = binary.BigEndian.Uint64(payload[24:])
+	} else {
+		if len(payload) < 20 {
+			return
+		}
+		scale = uint64(binary.BigEndian.Uint32(payload[12:]))
+		duration = uint64(binary.BigEndian.Uint32(payload[16:]))
+	}
+	if scale > 0 && duration > 0 {
+		metadata["duration"] = formatSeconds(float64(duration) / float64(scale))
+	}
+}
+
+// readTKHD reads the dimensions from a track header
+func readTKHD(payload []byte) (uint32, uint32) {
+	offset := 76
+	if len(payload) > 0 && payload[0] == 1 {
+		offset = 88
+	}
+	if len(payload) < offset+8 {
+		return 0, 0
+	}
+	// 16.16 fixed point
+	width := binary.BigEndian.Uint32(payload[offset:]) >> 16
+	height := binary.BigEndian.Uint32(payload[offset+4:]) >> 16
+	return width, height
+}
+
+// ebmlVint reads an ebml variable size integer, the marker
+// bit is kept for ids and removed for sizes
+func ebmlVint(data []byte, keepMarker bool) (uint64, int, bool) {
+	if len(data) < 1 || data[0] == 0 {
+		return 0, 0, false
+	}
+	length := 1
+	for mask := byte(0x80); data[0]&mask == 0; mask >>= 1 {
+		length++
+	}
+	if length > len(data) {
+		return 0, 0, false
+	}
+	value := uint64(data[0])
+	if !keepMarker {
+		value &= uint64(0xFF >> length)
+	}
+	allOnes := value == uint64(0xFF>>length)
+	for _, c := range data[1:length] {
+		value = value<<8 | uint64(c)
+		allOnes = allOnes && c == 0xFF
+	}
+	if !keepMarker && allOnes {
+		// unknown size
+		return math.MaxUint64, length, true
+	}
+	return value, length, true
+}
+
+// ebmlUint decodes an ebml unsigned integer
+func ebmlUint(data []byte) uint64 {
+	var value uint64
+	for _, c := range data {
+		value = value<<8 | uint64(c)
+	}
+	return value
+}
+
+// ebmlFloat decodes an ebml float
+func ebmlFloat(data []byte) float64 {
+	switch len(data) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(data))
+	}
+	return 0
+}
+
+// walkEBML calls fn for each element in data, stops
+// when fn returns false
+func walkEBML(data []byte, fn func(id uint64, payload []byte) bool) {
+	pos := 0
+	for pos < len(data) {
+		id, idLen, ok := ebmlVint(data[pos:], true)
+		if !ok {
+			return
+		}
+		size, sizeLen, ok := ebmlVint(data[pos+idLen:], false)
+		if !ok {
+			return
+		}
+		start := pos + idLen + sizeLen
+		end := len(data)
+		if size < uint64(len(data)-start) {
+			end = start + int(size)
+		}
+		if !fn(id, data[start:end]) {
+			return
+		}
+		pos = end
+	}
+}
+
+// readMatroska reads the duration and the dimensions
+// of a matroska/webm file
+func readMatroska(data []byte) map[string]string {
+	metadata := make(map[string]string)
+	scale := uint64(1000000)
+	var duration float64
+	var width, height uint64
+
+	walkEBML(data, func(id uint64, payload []byte) bool {
+		if id != ebmlSegment {
+			return true
+		}
+		walkEBML(payload, func(id uint64, payload []byte) bool {
+			switch id {
+			case ebmlInfo:
+				walkEBML(payload, func(id uint64, payload []byte) bool {
+					switch id {
+					case ebmlTimecodeScale:
+						scale = ebmlUint(payload)
+					case ebmlDuration:
+						duration = ebmlFloat(payload)
+					}
+					return true
+				})
+			case ebmlTracks:
+				walkEBML(payload, func(id uint64, payload []byte) bool {
+					if id != ebmlTrackEntry {
+						return true
+					}
+					walkEBML(payload, func(id uint64, payload []byte) bool {
+						if id != ebmlVideo {
+							return true
+						}
+						walkEBML(payload, func(id uint64, payload []byte) bool {
+							switch id {
+							case ebmlPixelWidth:
+								width = max(width, ebmlUint(payload))
+							case ebmlPixelHeight:
+								height = max(height, ebmlUint(payload))
+							}
+							return true
+						})
+						return true
+					})
+					return true
+				})
+			case ebmlCluster:
+				// media data, no more header
+				return false
+			}
+			return true
+		})
+		return false
+	})
+
+	if duration > 0 {
+		seconds := duration * float64(scale) / 1e9
+		metadata["duration"] = formatSeconds(seconds)
+	}
+	if width > 0 && height > 0 {
+		metadata["width"] = fmt.Sprintf("%d", width)
+		metadata["height"] = fmt.Sprintf("%d", height)
+	}
+	return metadata
+}
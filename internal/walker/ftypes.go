@@ -18,6 +18,12 @@ func getMime(path string) string {
 		log.Debugf("filetype open error: %v", err)
 		return ""
 	}
+	defer func() {
+		err := file.Close()
+		if err != nil {
+			log.Error(err)
+		}
+	}()
 	head := make([]byte, headerSize)
 	_, err = file.Read(head)
 	if err != nil {
@@ -7,15 +7,19 @@ package walker
 
 import (
 	"fmt"
-	"io/fs"
+	"os"
 	"path/filepath"
 	"regexp"
+	"sync"
+	"sync/atomic"
 
 	"github.com/deadc0de6/gocatcli/internal/helpers"
 	"github.com/deadc0de6/gocatcli/internal/log"
 	"github.com/deadc0de6/gocatcli/internal/node"
+	"github.com/deadc0de6/gocatcli/internal/search"
 	"github.com/deadc0de6/gocatcli/internal/tree"
 	"github.com/deadc0de6/gocatcli/internal/walker/archives"
+	"github.com/deadc0de6/gocatcli/internal/walker/extractors"
 
 	"github.com/pterm/pterm"
 )
@@ -23,114 +27,238 @@ import (
 // Walker a walker
 type Walker struct {
 	tree         *tree.Tree
-	withChecksum bool
+	checksumAlgo string // no checksum if empty
 	withArchive  bool
 	ignores      []*regexp.Regexp
 	noMime       bool
+	workers      int
+	incremental  bool
+	withMetadata bool
+	content      *search.Content // full-text index updated if not nil
+	contentLimit int64           // no text extracted if zero
 }
 
-// walk walks a dir - returns nb children and error if any
-func (w *Walker) walk(storageID int, walkPath string, storagePath string, parent node.Node, spinner *pterm.SpinnerPrinter) (int64, error) {
-	var cnt int64
-	//log.Debugf("walking %s with parent %s", walkPath, parent.GetName())
-	if spinner != nil {
-		spinner.UpdateText(fmt.Sprintf("indexing %s", walkPath))
+// Stats indexing statistics
+type Stats struct {
+	Total    int64 // entries indexed
+	Added    int64 // new entries
+	Modified int64 // entries that changed since last index
+	Removed  int64 // entries not found anymore
+	Kept     int64 // entries unchanged since last index
+}
+
+// walkState the state shared by the jobs of a walk
+type walkState struct {
+	storageID   int
+	storagePath string
+	cnt         atomic.Int64
+	added       atomic.Int64
+	modified    atomic.Int64
+	kept        atomic.Int64
+	wg          sync.WaitGroup
+	slots       chan struct{} // one per additional worker
+	spinner     *pterm.SpinnerPrinter
+	spinnerLock sync.Mutex
+}
+
+// run runs job on a free worker or inline if all are busy,
+// a job only ever mutates the node it was given
+func (s *walkState) run(job func()) {
+	select {
+	case s.slots <- struct{}{}:
+		s.wg.Add(1)
+		go func() {
+			defer func() {
+				<-s.slots
+				s.wg.Done()
+			}()
+			job()
+		}()
+	default:
+		job()
 	}
-	children := parent.GetDirectChildren()
-	err := filepath.WalkDir(walkPath, func(pathUnderRoot string, dentry fs.DirEntry, err error) error {
-		if err != nil {
-			log.Error(err)
-			// skipping
-			return nil
-		}
+}
 
-		if pathUnderRoot == walkPath {
-			//log.Debugf("skipping \"%s\"", pathUnderRoot)
-			// skipping
-			return nil
-		}
-		if dentry == nil {
-			log.Errorf("cannot index %s", walkPath)
-			// skipping
-			return nil
-		}
+func (s *walkState) updateSpinner(text string) {
+	if s.spinner == nil {
+		return
+	}
+	s.spinnerLock.Lock()
+	defer s.spinnerLock.Unlock()
+	s.spinner.UpdateText(text)
+}
+
+// walk walks a dir, the direct children of parent are created or
+// updated in order and their content processed by the workers
+func (w *Walker) walk(state *walkState, walkPath string, parent node.Node) {
+	//log.Debugf("walking %s with parent %s", walkPath, parent.GetName())
+	state.updateSpinner(fmt.Sprintf("indexing %s", walkPath))
 
+	info, err := os.Lstat(walkPath)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	if !info.IsDir() {
+		log.Errorf("cannot index %s", walkPath)
+		return
+	}
+
+	children := parent.GetDirectChildren()
+	// entries are sorted by name
+	dentries, err := os.ReadDir(walkPath)
+	if err != nil {
+		log.Error(err)
+		// process what could be read
+	}
+	for _, dentry := range dentries {
+		pathUnderRoot := filepath.Join(walkPath, dentry.Name())
 		log.Debugf("indexing %s", pathUnderRoot)
 
 		info, err := dentry.Info()
 		if err != nil {
 			log.Errorf("cannot index %s: %v", walkPath, err)
-			return nil
+			continue
 		}
 
 		if w.mustIgnore(pathUnderRoot) {
 			// skipping
 			if info.IsDir() {
 				log.Infof("ignoring directory \"%s\"...", pathUnderRoot)
-				return filepath.SkipDir
+				continue
 			}
 			log.Infof("ignoring \"%s\"", pathUnderRoot)
-			return nil
+			continue
 		}
 
 		// create or update child
 		child, ok := children[info.Name()]
+		unchanged := false
 		if !ok {
 			// create
 			log.Debugf("node \"%s\" created", info.Name())
-			fpath, err := filepath.Rel(storagePath, pathUnderRoot)
+			fpath, err := filepath.Rel(state.storagePath, pathUnderRoot)
 			if err != nil {
-				return err
+				log.Error(err)
+				continue
 			}
-			child = node.NewFileNode(storageID, fpath, info)
+			child = node.NewFileNode(state.storageID, fpath, info)
 			parent.AddChild(child)
-			//child = parent.GetDirectChildren()[info.Name()] // get the correct pointer
+			state.added.Add(1)
 		} else {
 			// update
 			log.Debugf("updating node \"%s\"", info.Name())
+			unchanged = child.Unchanged(info)
 			child.Update(info)
-		}
-		cnt++
-
-		if child != nil {
-			//log.Debugf("walker found path:\"%s\" (parent:\"%s\")", pathUnderRoot, parent.GetName())
-
-			// handle directory
-			if node.IsDir(child) {
-				subcnt, err := w.walk(storageID, pathUnderRoot, storagePath, child, spinner)
-				if err != nil {
-					log.Error(err)
-				}
-				cnt += subcnt
-				return filepath.SkipDir
+			if unchanged {
+				state.kept.Add(1)
+			} else {
+				state.modified.Add(1)
 			}
+		}
+		state.cnt.Add(1)
 
-			// handle mime type
-			if !w.noMime {
-				child.Mime = getMime(pathUnderRoot)
-			}
+		if child == nil {
+			continue
+		}
+		//log.Debugf("walker found path:\"%s\" (parent:\"%s\")", pathUnderRoot, parent.GetName())
 
-			// handle checksums
-			if w.withChecksum {
-				chk, err := helpers.ChecksumFileContent(pathUnderRoot)
-				if err != nil {
-					log.Error(err)
-				} else {
-					log.Debugf("checksumming %s", pathUnderRoot)
-					child.Checksum = chk
-				}
-			}
+		// handle directory
+		if node.IsDir(child) {
+			state.run(func() {
+				w.walk(state, pathUnderRoot, child)
+			})
+			continue
+		}
 
-			// handle archives
-			if w.withArchive && archives.IsArchive(pathUnderRoot) {
-				log.Debugf("%s is archive", pathUnderRoot)
-				processArchive(pathUnderRoot, storageID, storagePath, child)
-			}
+		state.run(func() {
+			w.processFile(state, pathUnderRoot, child, unchanged)
+		})
+	}
+}
+
+// processFile fills the file node from its content, in incremental
+// mode what was stored is kept for files that did not change
+func (w *Walker) processFile(state *walkState, path string, child *node.FileNode, unchanged bool) {
+	reuse := w.incremental && unchanged
+	if reuse {
+		log.Debugf("%s unchanged", path)
+	}
+
+	// handle mime type
+	if !w.noMime && !reuse {
+		child.Mime = getMime(path)
+	}
+
+	// handle metadata
+	if w.withMetadata && !(reuse && child.Metadata != nil) {
+		child.Metadata = extractors.Extract(path, mimeOf(path, child))
+	} else if !w.withMetadata && !unchanged {
+		// do not keep the metadata of a previous content
+		child.Metadata = nil
+	}
+
+	// handle content
+	if w.content != nil {
+		if w.contentLimit > 0 && !(reuse && w.content.Has(child.ID)) {
+			text := extractors.ExtractText(path, mimeOf(path, child), w.contentLimit)
+			w.content.Set(state.storageID, child.ID, text)
+		} else if w.contentLimit < 1 && !unchanged {
+			// do not keep the text of a previous content
+			w.content.Set(state.storageID, child.ID, "")
 		}
-		return nil
-	})
+	}
 
-	return cnt, err
+	// handle checksums
+	algo, _ := helpers.SplitChecksum(child.Checksum)
+	reuseChecksum := reuse && len(child.Checksum) > 0 && algo == w.checksumAlgo
+	if len(w.checksumAlgo) > 0 && !reuseChecksum {
+		chk, err := helpers.ChecksumFileContent(path, w.checksumAlgo)
+		if err != nil {
+			log.Error(err)
+		} else {
+			log.Debugf("checksumming %s", path)
+			child.Checksum = chk
+		}
+	} else if len(w.checksumAlgo) < 1 && !unchanged {
+		// do not keep the checksum of a previous content
+		child.Checksum = ""
+	}
+
+	// handle archives
+	if !w.withArchive {
+		return
+	}
+	if reuse && child.Type == node.FileTypeArchive {
+		// keep archived entries
+		child.MarkSeen()
+		return
+	}
+	if archives.IsArchive(path) {
+		log.Debugf("%s is archive", path)
+		processArchive(path, state.storageID, state.storagePath, child)
+	}
+}
+
+// mimeOf returns the mime type of the file,
+// detected if it was not (see --nomime)
+func mimeOf(path string, child *node.FileNode) string {
+	if len(child.Mime) > 0 {
+		return child.Mime
+	}
+	return getMime(path)
+}
+
+// countEntries returns the number of filesystem entries under n
+func countEntries(n node.Node) int64 {
+	if n.GetType() == node.FileTypeArchived {
+		return 0
+	}
+	var cnt int64 = 1
+	for _, child := range n.GetDirectChildren() {
+		cnt += countEntries(child)
+	}
+	return cnt
 }
 
 func processArchive(path string, storageID int, storagePath string, child *node.FileNode) {
@@ -141,6 +269,10 @@ func processArchive(path string, storageID int, storagePath string, child *node.
 	//	}
 	//}()
 	archived, _ := archives.GetFiles(path)
+	// drop the entries of a previous index
+	// but keep their annotations
+	previous := child.GetDirectChildren()
+	child.Children = nil
 	for _, arc := range archived {
 		fpath, err := filepath.Rel(storagePath, path)
 		if err != nil {
@@ -148,15 +280,34 @@ func processArchive(path string, storageID int, storagePath string, child *node.
 			return
 		}
 		sub := node.NewArchivedFileNode(storageID, fpath, arc.FileInfo, arc.Path)
+		sub.KeepAnnotations(previous[sub.GetName()])
 		child.AddChild(sub)
 	}
 	child.Type = node.FileTypeArchive
 }
 
 // Walk walks the filesystem hierarchy
-func (w *Walker) Walk(storageID int, walkPath string, storage *node.StorageNode, spinner *pterm.SpinnerPrinter) (int64, uint64, error) {
+func (w *Walker) Walk(storageID int, walkPath string, storage *node.StorageNode, spinner *pterm.SpinnerPrinter) (*Stats, uint64, error) {
 	// index everything
-	cnt, err := w.walk(storageID, walkPath, walkPath, storage, spinner)
+	storage.SetDirty(true)
+	if len(w.checksumAlgo) > 0 {
+		storage.ChecksumAlgo = w.checksumAlgo
+	}
+	state := &walkState{
+		storageID:   storageID,
+		storagePath: walkPath,
+		slots:       make(chan struct{}, w.workers-1),
+		spinner:     spinner,
+	}
+	log.Debugf("walking with %d worker(s)", w.workers)
+	w.walk(state, walkPath, storage)
+	state.wg.Wait()
+	stats := &Stats{
+		Total:    state.cnt.Load(),
+		Added:    state.added.Load(),
+		Modified: state.modified.Load(),
+		Kept:     state.kept.Load(),
+	}
 
 	type parentChild struct {
 		parent node.Node
@@ -167,6 +318,7 @@ func (w *Walker) Walk(storageID int, walkPath string, storage *node.StorageNode,
 	var toRemove []*parentChild
 	callback := func(n node.Node, _ int, parent node.Node) bool {
 		if !n.Seen() {
+			stats.Removed += countEntries(n)
 			toRemove = append(toRemove, &parentChild{
 				parent: parent,
 				child:  n,
@@ -198,7 +350,7 @@ func (w *Walker) Walk(storageID int, walkPath string, storage *node.StorageNode,
 
 	log.Debugf("done indexing...")
 
-	return cnt, storage.Size, err
+	return stats, storage.Size, nil
 }
 
 func (w *Walker) mustIgnore(path string) bool {
@@ -212,13 +364,26 @@ func (w *Walker) mustIgnore(path string) bool {
 }
 
 // NewWalker creates a new walker on path
-func NewWalker(t *tree.Tree, withChecksum bool, withArchive bool, ignores []*regexp.Regexp, noMime bool) *Walker {
+// with at most workers jobs running in parallel
+// files are checksummed with checksumAlgo unless empty and
+// in incremental mode, unchanged files are not processed again,
+// withMetadata runs the metadata extractors on the files and
+// the text of the files up to contentLimit bytes is set in content
+func NewWalker(t *tree.Tree, checksumAlgo string, withArchive bool, ignores []*regexp.Regexp, noMime bool, workers int, incremental bool, withMetadata bool, content *search.Content, contentLimit int64) *Walker {
+	if workers < 1 {
+		workers = 1
+	}
 	w := Walker{
 		tree:         t,
-		withChecksum: withChecksum,
+		checksumAlgo: checksumAlgo,
 		withArchive:  withArchive,
 		ignores:      ignores,
 		noMime:       noMime,
+		workers:      workers,
+		incremental:  incremental,
+		withMetadata: withMetadata,
+		content:      content,
+		contentLimit: contentLimit,
 	}
 	return &w
 }
@@ -0,0 +1,189 @@
+#!/usr/bin/env python3
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# generate minimal media files and documents
+# ./tests-ng/media.py /tmp/media
+#
+
+
+import os
+import struct
+import argparse
+import zipfile
+import zlib
+
+
+NAME = 'media'
+
+
+def tiff_entry(tag, typ, count, value):
+    """a big endian ifd entry, value is the 4 bytes field"""
+    return struct.pack('>HHI', tag, typ, count) + value
+
+
+def jpeg(path):
+    """a jpeg with exif camera, date and gps"""
+    make = b'Canon\x00'
+    model = b'Canon EOS 5D\x00'
+    date = b'2021:06:15 10:30:00\x00'
+    # layout: header(8) ifd0(2+4*12+4) exif(2+12+4) gps(2+4*12+4) data
+    ifd0_off = 8
+    exif_off = ifd0_off + 2 + 4 * 12 + 4
+    gps_off = exif_off + 2 + 12 + 4
+    data_off = gps_off + 2 + 4 * 12 + 4
+    make_off = data_off
+    model_off = make_off + len(make)
+    date_off = model_off + len(model)
+    lat_off = date_off + len(date)
+    lon_off = lat_off + 24
+
+    tiff = b'MM\x00\x2a' + struct.pack('>I', ifd0_off)
+    tiff += struct.pack('>H', 4)
+    tiff += tiff_entry(0x010F, 2, len(make), struct.pack('>I', make_off))
+    tiff += tiff_entry(0x0110, 2, len(model), struct.pack('>I', model_off))
+    tiff += tiff_entry(0x8769, 4, 1, struct.pack('>I', exif_off))
+    tiff += tiff_entry(0x8825, 4, 1, struct.pack('>I', gps_off))
+    tiff += struct.pack('>I', 0)
+    tiff += struct.pack('>H', 1)
+    tiff += tiff_entry(0x9003, 2, len(date), struct.pack('>I', date_off))
+    tiff += struct.pack('>I', 0)
+    tiff += struct.pack('>H', 4)
+    tiff += tiff_entry(0x0001, 2, 2, b'N\x00\x00\x00')
+    tiff += tiff_entry(0x0002, 5, 3, struct.pack('>I', lat_off))
+    tiff += tiff_entry(0x0003, 2, 2, b'E\x00\x00\x00')
+    tiff += tiff_entry(0x0004, 5, 3, struct.pack('>I', lon_off))
+    tiff += struct.pack('>I', 0)
+    tiff += make + model + date
+    # 46°30'0" N, 6°36'0" E
+    tiff += struct.pack('>IIIIII', 46, 1, 30, 1, 0, 1)
+    tiff += struct.pack('>IIIIII', 6, 1, 36, 1, 0, 1)
+
+    app1 = b'Exif\x00\x00' + tiff
+    # one component of 640x480
+    sof = struct.pack('>BHHB', 8, 480, 640, 1) + b'\x01\x11\x00'
+    sos = struct.pack('>B', 1) + b'\x01\x00' + b'\x00\x3f\x00'
+    with open(path, 'wb') as f:
+        f.write(b'\xff\xd8')
+        f.write(b'\xff\xe1' + struct.pack('>H', len(app1) + 2) + app1)
+        f.write(b'\xff\xc0' + struct.pack('>H', len(sof) + 2) + sof)
+        f.write(b'\xff\xda' + struct.pack('>H', len(sos) + 2) + sos)
+        f.write(b'\xff\xd9')
+
+
+def id3_frame(fid, text):
+    """an id3v2.3 latin-1 text frame"""
+    payload = b'\x00' + text.encode('latin-1')
+    return fid.encode() + struct.pack('>IH', len(payload), 0) + payload
+
+
+def mp3(path):
+    """an mp3 with an id3v2.3 tag"""
+    frames = id3_frame('TIT2', 'Blue Monday')
+    frames += id3_frame('TPE1', 'New Order')
+    frames += id3_frame('TALB', 'Power, Corruption & Lies')
+    frames += id3_frame('TYER', '1983')
+    frames += b'\x00' * 16
+    size = len(frames)
+    syncsafe = bytes([(size >> 21) & 0x7F, (size >> 14) & 0x7F,
+                      (size >> 7) & 0x7F, size & 0x7F])
+    with open(path, 'wb') as f:
+        f.write(b'ID3\x03\x00\x00' + syncsafe + frames)
+        f.write(b'\xff\xfb\x90\x00' + b'\x00' * 413)
+
+
+def flac(path):
+    """a flac of 2 minutes with vorbis comments"""
+    rate = 44100
+    samples = rate * 120
+    info = struct.pack('>HH', 4096, 4096) + b'\x00' * 6
+    # 20 bits rate, 3 bits channels-1, 5 bits bps-1, 36 bits samples
+    packed = (rate << 44) | (1 << 41) | (15 << 36) | samples
+    info += packed.to_bytes(8, 'big') + b'\x00' * 16
+
+    comments = [b'TITLE=Clair de lune', b'ARTIST=Debussy', b'DATE=1905-01-01']
+    vendor = b'gocatcli'
+    block = struct.pack('<I', len(vendor)) + vendor
+    block += struct.pack('<I', len(comments))
+    for comment in comments:
+        block += struct.pack('<I', len(comment)) + comment
+
+    with open(path, 'wb') as f:
+        f.write(b'fLaC')
+        f.write(bytes([0]) + len(info).to_bytes(3, 'big') + info)
+        f.write(bytes([0x80 | 4]) + len(block).to_bytes(3, 'big') + block)
+
+
+def atom(typ, payload):
+    """an mp4 atom"""
+    return struct.pack('>I', len(payload) + 8) + typ + payload
+
+
+def mp4(path):
+    """an mp4 of 90 seconds in 1920x1080"""
+    mvhd = struct.pack('>B3sIIII', 0, b'\x00' * 3, 0, 0, 1000, 90000)
+    mvhd += b'\x00' * 80
+    tkhd = struct.pack('>B3sIIIII', 0, b'\x00' * 3, 0, 0, 1, 0, 90000)
+    tkhd += b'\x00' * 52
+    tkhd += struct.pack('>II', 1920 << 16, 1080 << 16)
+    moov = atom(b'moov', atom(b'mvhd', mvhd) +
+                atom(b'trak', atom(b'tkhd', tkhd)))
+    with open(path, 'wb') as f:
+        f.write(atom(b'ftyp', b'isom' + struct.pack('>I', 512) + b'isom'))
+        f.write(atom(b'free', b'\x00' * 16))
+        f.write(atom(b'mdat', b'\x00' * 64))
+        f.write(moov)
+
+
+def pdf(path):
+    """a pdf of 2 pages with the info in an object stream"""
+    info = b'<< /Title (Annual \\(draft\\) report) /Author <FEFF004A006F0065> >>'
+    objstm = zlib.compress(b'5 0 ' + info)
+    text = zlib.compress(b'BT /F1 12 Tf 72 712 Td (Quarterly revenue grew) Tj '
+                         b'0 -14 Td [(by ) -20 (twelve) ( percent)] TJ ET')
+    with open(path, 'wb') as f:
+        f.write(b'%PDF-1.5\n')
+        f.write(b'1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n')
+        f.write(b'2 0 obj\n<< /Type /Pages /Kids [3 0 R 4 0 R] /Count 2 >>\nendobj\n')
+        f.write(b'3 0 obj\n<< /Type /Page /Parent 2 0 R /Contents 7 0 R >>\nendobj\n')
+        f.write(b'4 0 obj\n<< /Type /Page /Parent 2 0 R >>\nendobj\n')
+        f.write(b'6 0 obj\n<< /Type /ObjStm /N 1 /First 4 /Filter /FlateDecode /Length ')
+        f.write(str(len(objstm)).encode() + b' >>\nstream\n')
+        f.write(objstm + b'\nendstream\nendobj\n')
+        f.write(b'7 0 obj\n<< /Filter /FlateDecode /Length ')
+        f.write(str(len(text)).encode() + b' >>\nstream\n')
+        f.write(text + b'\nendstream\nendobj\n')
+        f.write(b'trailer\n<< /Root 1 0 R /Info 5 0 R >>\n%%EOF\n')
+
+
+def docx(path):
+    """a docx of 2 paragraphs"""
+    body = ('<?xml version="1.0" encoding="UTF-8"?>'
+            '<w:document xmlns:w="http://schemas.openxmlformats.org/'
+            'wordprocessingml/2006/main"><w:body>'
+            '<w:p><w:r><w:t>Minutes of the board meeting</w:t></w:r></w:p>'
+            '<w:p><w:r><w:t>The budget was </w:t></w:r>'
+            '<w:r><w:t>approved unanimously</w:t></w:r></w:p>'
+            '</w:body></w:document>')
+    with zipfile.ZipFile(path, 'w') as z:
+        z.writestr('[Content_Types].xml', '<?xml version="1.0"?><Types/>')
+        z.writestr('word/document.xml', body)
+
+
+def main():
+    """entry point"""
+    parser = argparse.ArgumentParser(prog=NAME)
+    parser.add_argument('path', help='directory to create the files in')
+    args = parser.parse_args()
+
+    os.makedirs(args.path, exist_ok=True)
+    jpeg(os.path.join(args.path, 'photo.jpg'))
+    mp3(os.path.join(args.path, 'song.mp3'))
+    flac(os.path.join(args.path, 'piano.flac'))
+    mp4(os.path.join(args.path, 'movie.mp4'))
+    pdf(os.path.join(args.path, 'report.pdf'))
+    docx(os.path.join(args.path, 'minutes.docx'))
+
+
+if __name__ == '__main__':
+    main()
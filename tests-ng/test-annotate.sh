@@ -0,0 +1,86 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test tags and notes on entries
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+out="${tmpd}/output.txt"
+src="${tmpd}/src"
+
+mkdir -p "${src}/dir/sub"
+echo "hello" > "${src}/dir/a.txt"
+echo "world" > "${src}/dir/sub/b.txt"
+echo "other" > "${src}/c.txt"
+
+# strip colors
+nocolor() {
+  sed -e 's/\x1b\[[0-9;]*m//g'
+}
+
+for catalog in "${tmpd}/catalog.json" "${tmpd}/catalog.sqlite"; do
+  echo ">>> testing with \"$(basename "${catalog}")\" <<<"
+  "${bin}" index -a -C -c "${catalog}" "${src}" drive
+
+  echo ">>> test tag and note <<<"
+  "${bin}" tag -c "${catalog}" "drive/dir/a.txt" important
+  "${bin}" tag -c "${catalog}" "drive/dir/a.txt" laptop
+  "${bin}" tag -c "${catalog}" "drive/dir/sub" keep
+  "${bin}" note -c "${catalog}" "drive/dir/a.txt" "copied from the old laptop"
+  "${bin}" ls -l -c "${catalog}" "drive/dir" | nocolor > "${out}"
+  cat_file "${out}"
+  grep -q '^a.txt .*note:copied from the old laptop tags:important,laptop' "${out}" || (echo "bad a.txt annotations" && exit 1)
+  grep -q '^sub .*tags:keep' "${out}" || (echo "bad sub annotations" && exit 1)
+
+  echo ">>> test find <<<"
+  "${bin}" find -c "${catalog}" -q 'tag=important' | nocolor > "${out}"
+  [ "$(wc -l < "${out}")" != "1" ] && echo "expecting 1 entry" && exit 1
+  grep -q '^drive/dir/a.txt' "${out}" || (echo "tag not found" && exit 1)
+  "${bin}" find -c "${catalog}" -q 'note~laptop' | nocolor > "${out}"
+  grep -q '^drive/dir/a.txt' "${out}" || (echo "note not found" && exit 1)
+  "${bin}" find -c "${catalog}" -q 'tag=keep' | nocolor > "${out}"
+  grep -q '^drive/dir/sub' "${out}" || (echo "dir tag not found" && exit 1)
+
+  echo ">>> test json <<<"
+  "${bin}" ls -c "${catalog}" -f ndjson "drive/dir/a.txt" | grep -q '"note":"copied from the old laptop"' || (echo "no note in json" && exit 1)
+
+  echo ">>> test re-index keeps annotations <<<"
+  echo "changed" > "${src}/dir/a.txt"
+  "${bin}" index -a -C -f -c "${catalog}" "${src}" drive
+  "${bin}" ls -l -c "${catalog}" "drive/dir" | nocolor > "${out}"
+  grep -q '^a.txt .*note:copied from the old laptop tags:important,laptop' "${out}" || (echo "annotations lost" && exit 1)
+  grep -q '^sub .*tags:keep' "${out}" || (echo "dir annotations lost" && exit 1)
+
+  echo ">>> test untag and remove note <<<"
+  "${bin}" untag -c "${catalog}" "drive/dir/a.txt" laptop
+  "${bin}" note -c "${catalog}" "drive/dir/a.txt" ""
+  "${bin}" ls -l -c "${catalog}" "drive/dir/a.txt" | nocolor > "${out}"
+  cat_file "${out}"
+  grep -q 'tags:important$' "${out}" || (echo "not untagged" && exit 1)
+  grep -q 'note:' "${out}" && echo "note not removed" && exit 1
+
+  echo ">>> test bad paths <<<"
+  "${bin}" tag -c "${catalog}" "drive/nope" foo && echo "should fail" && exit 1
+  "${bin}" note -c "${catalog}" "drive" foo && echo "storage should fail" && exit 1
+
+  # reset for the next catalog
+  echo "hello" > "${src}/dir/a.txt"
+done
+
+echo "test $(basename "${0}") OK!"
+exit 0
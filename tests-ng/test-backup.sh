@@ -0,0 +1,98 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test catalog backups and restore
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+catalog="${tmpd}/catalog"
+out="${tmpd}/output.txt"
+
+echo ">>> test backups <<<"
+"${bin}" index --backups 2 -c "${catalog}" "${cur}/../internal" internal
+[ -e "${catalog}.bak.1" ] && echo "backup created for new catalog" && exit 1
+"${bin}" index --backups 2 -c "${catalog}" "${cur}/../tests-ng" testsng
+[ ! -e "${catalog}.bak.1" ] && echo "backup not created" && exit 1
+"${bin}" --backups 2 -c "${catalog}" storage tag testsng tag1
+"${bin}" --backups 2 -c "${catalog}" storage tag testsng tag2
+[ ! -e "${catalog}.bak.2" ] && echo "backup 2 not created" && exit 1
+[ -e "${catalog}.bak.3" ] && echo "too many backups" && exit 1
+
+"${bin}" -c "${catalog}" catalog backups > "${out}"
+cat_file "${out}"
+cnt=$(wc -l "${out}" | awk '{print $1}')
+[ "${cnt}" != "2" ] && echo "expecting 2 backups (${cnt})" && exit 1
+
+# no backup
+"${bin}" --backups 0 -c "${catalog}" storage untag testsng tag2
+cnt=$(find "${tmpd}" -name 'catalog.bak.*' | wc -l)
+[ "${cnt}" != "2" ] && echo "expecting 2 backups (${cnt})" && exit 1
+
+echo ">>> test restore <<<"
+# bak.2 has no tag
+"${bin}" --backups 2 -c "${catalog}" catalog restore -f 2
+"${bin}" -c "${catalog}" storage list | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+cat_file "${out}"
+grep 'tags:' "${out}" && (echo "bad restore" && exit 1)
+
+# the catalog before restore (with tag1) is the most recent backup
+"${bin}" --backups 2 -c "${catalog}" catalog restore -f
+"${bin}" -c "${catalog}" storage list | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+cat_file "${out}"
+grep 'tags:tag1$' "${out}" || (echo "bad restore" && exit 1)
+
+# a restore never removes backups
+before=$(find "${tmpd}" -name 'catalog.bak.*' | wc -l)
+"${bin}" --backups 0 -c "${catalog}" catalog restore -f 2
+cnt=$(find "${tmpd}" -name 'catalog.bak.*' | wc -l)
+[ "${cnt}" != "${before}" ] && echo "expecting ${before} backups (${cnt})" && exit 1
+"${bin}" --backups 1 -c "${catalog}" catalog restore -f 1
+cnt=$(find "${tmpd}" -name 'catalog.bak.*' | wc -l)
+[ "${cnt}" != "$((before+1))" ] && echo "expecting $((before+1)) backups (${cnt})" && exit 1
+
+# no temporary file left
+cnt=$(find "${tmpd}" -name '.catalog.tmp-*' -o -name '*.restore' | wc -l)
+[ "${cnt}" != "0" ] && echo "temporary files left" && exit 1
+
+echo ">>> test backups keep the catalog extensions <<<"
+for ext in catalog.gz catalog.zst db; do
+  other="${tmpd}/other.${ext}"
+  "${bin}" index --backups 2 -c "${other}" "${cur}/../internal" internal
+  "${bin}" index --backups 2 -c "${other}" "${cur}/../tests-ng" testsng
+  [ ! -e "${tmpd}/other.bak.1.${ext}" ] && echo "backup not created for ${ext}" && exit 1
+  "${bin}" -c "${other}" catalog backups > "${out}"
+  cat_file "${out}"
+  grep -q "other.bak.1.${ext}" "${out}" || (echo "backup not listed for ${ext}" && exit 1)
+  # the backup is read with the backend of the catalog
+  "${bin}" -c "${tmpd}/other.bak.1.${ext}" ls > "${out}"
+  cat_file "${out}"
+  grep -q 'testsng' "${out}" && echo "bad backup content for ${ext}" && exit 1
+  grep -q 'internal' "${out}" || (echo "cannot read backup for ${ext}" && exit 1)
+done
+
+# backups are not loaded with a directory of catalogs
+mkdir -p "${tmpd}/dir"
+"${bin}" index -c "${tmpd}/dir/a.catalog" "${cur}/../internal" internal
+"${bin}" index -c "${tmpd}/dir/a.catalog" "${cur}/../tests-ng" testsng
+[ ! -e "${tmpd}/dir/a.bak.1.catalog" ] && echo "backup not created" && exit 1
+"${bin}" -c "${tmpd}/dir" ls > "${out}"
+cat_file "${out}"
+grep -q 'a.bak.1' "${out}" && echo "backup loaded as a catalog" && exit 1
+
+echo "test $(basename "${0}") OK!"
+exit 0
@@ -0,0 +1,92 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test checksum algorithms
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+catalog="${tmpd}/catalog.json"
+out="${tmpd}/output.txt"
+src="${tmpd}/src"
+mkdir -p "${src}"
+echo "some content" > "${src}/file"
+
+# $1: algorithm
+# $2: digest
+check_checksum()
+{
+  "${bin}" -c "${catalog}" ls -r --format csv > "${out}"
+  cat_file "${out}"
+  grep "^file,.*,${1}:${2}," "${out}" || (echo "bad ${1} checksum" && exit 1)
+  "${bin}" -c "${catalog}" storage list | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+  grep "checksum:${1} " "${out}" || (echo "${1} not recorded on storage" && exit 1)
+}
+
+echo ">>> test default checksum <<<"
+"${bin}" index -C -c "${catalog}" "${src}" src
+check_checksum md5 "$(md5sum "${src}/file" | awk '{print $1}')"
+
+echo ">>> test sha256 checksum <<<"
+"${bin}" index -f --checksum-algo sha256 -c "${catalog}" "${src}" src
+check_checksum sha256 "$(sha256sum "${src}/file" | awk '{print $1}')"
+"${bin}" -c "${catalog}" find --format csv file | grep ",sha256:" || (echo "algo not shown by find" && exit 1)
+
+echo ">>> test storage algorithm is kept <<<"
+"${bin}" index -f -u -C -c "${catalog}" "${src}" src
+check_checksum sha256 "$(sha256sum "${src}/file" | awk '{print $1}')"
+
+echo ">>> test sha1 checksum <<<"
+# checksums of another algorithm are not reused
+"${bin}" index -f -u --checksum-algo sha1 -c "${catalog}" "${src}" src
+check_checksum sha1 "$(sha1sum "${src}/file" | awk '{print $1}')"
+
+if command -v b2sum >/dev/null 2>&1; then
+  echo ">>> test blake2b checksum <<<"
+  "${bin}" index -f --checksum-algo blake2b -c "${catalog}" "${src}" src
+  check_checksum blake2b "$(b2sum -l 256 "${src}/file" | awk '{print $1}')"
+fi
+
+for algo in blake3 xxh64; do
+  echo ">>> test ${algo} checksum <<<"
+  "${bin}" index -f --checksum-algo "${algo}" -c "${catalog}" "${src}" src
+  check_checksum "${algo}" "[0-9a-f]*"
+done
+
+echo ">>> test unsupported algorithm <<<"
+"${bin}" index -f --checksum-algo crc32 -c "${catalog}" "${src}" src && (echo "unsupported algo accepted" && exit 1)
+
+echo ">>> test legacy md5 catalog <<<"
+"${bin}" index -f -C --checksum-algo md5 -c "${catalog}" "${src}" src
+sed -i -e 's/"checksum": "md5:/"md5": "/' -e '/"checksum_algo":/d' -e '/"schema":/d' "${catalog}"
+"${bin}" -c "${catalog}" catalog upgrade
+grep '"md5":' "${catalog}" && (echo "md5 field not migrated" && exit 1)
+check_checksum md5 "$(md5sum "${src}/file" | awk '{print $1}')"
+
+if command -v sqlite3 >/dev/null 2>&1; then
+  echo ">>> test legacy sqlite catalog <<<"
+  catalog="${tmpd}/catalog.sqlite"
+  "${bin}" index -C -c "${catalog}" "${src}" src
+  sqlite3 "${catalog}" "ALTER TABLE storages DROP COLUMN checksum_algo"
+  sqlite3 "${catalog}" "UPDATE nodes SET checksum = substr(checksum, 5)"
+  sqlite3 "${catalog}" "UPDATE info SET value = '2' WHERE key = 'schema'"
+  "${bin}" -c "${catalog}" catalog upgrade
+  check_checksum md5 "$(md5sum "${src}/file" | awk '{print $1}')"
+fi
+
+echo "test $(basename "${0}") OK!"
+exit 0
@@ -0,0 +1,131 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test storage rename and collections
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+out="${tmpd}/output.txt"
+
+mkdir -p "${tmpd}/one/dir" "${tmpd}/two" "${tmpd}/three"
+echo "abc" > "${tmpd}/one/dir/one.txt"
+echo "defgh" > "${tmpd}/two/two.txt"
+echo "ijk" > "${tmpd}/three/three.txt"
+
+# strip colors
+nocolor() {
+  sed -e 's/\x1b\[[0-9;]*m//g'
+}
+
+for catalog in "${tmpd}/catalog.json" "${tmpd}/catalog.sqlite"; do
+  echo ">>> testing with \"$(basename "${catalog}")\" <<<"
+
+  "${bin}" index -a -C -c "${catalog}" --collection "LTO tapes/2023" "${tmpd}/one" tape1
+  "${bin}" index -a -C -c "${catalog}" --collection "/LTO tapes/2024/" "${tmpd}/two" tape2
+  "${bin}" index -a -C -c "${catalog}" "${tmpd}/three" disk
+
+  echo ">>> test ls root <<<"
+  "${bin}" ls -c "${catalog}" | nocolor > "${out}"
+  cat_file "${out}"
+  [ "$(wc -l < "${out}")" != "2" ] && echo "expecting 2 lines" && exit 1
+  grep -q '^collection LTO tapes' "${out}" || (echo "no collection" && exit 1)
+  grep -q '^storage disk' "${out}" || (echo "no storage" && exit 1)
+  grep -q 'tape1' "${out}" && echo "tape1 should be in collection" && exit 1
+
+  echo ">>> test ls collection <<<"
+  "${bin}" ls -c "${catalog}" "LTO tapes" | nocolor > "${out}"
+  cat_file "${out}"
+  grep -q '^collection 2023' "${out}" || (echo "no 2023" && exit 1)
+  grep -q '^collection 2024' "${out}" || (echo "no 2024" && exit 1)
+  "${bin}" ls -c "${catalog}" "LTO tapes/2024" | nocolor > "${out}"
+  grep -q '^storage tape2' "${out}" || (echo "no tape2" && exit 1)
+
+  echo ">>> test ls through collection <<<"
+  "${bin}" ls -c "${catalog}" "LTO tapes/2023/tape1/dir" | nocolor > "${out}"
+  grep -q '^one.txt' "${out}" || (echo "one.txt not found" && exit 1)
+  # storage name still works
+  "${bin}" ls -c "${catalog}" "tape1/dir" | nocolor > "${out}"
+  grep -q '^one.txt' "${out}" || (echo "one.txt not found by storage name" && exit 1)
+
+  echo ">>> test find collection with search index <<<"
+  "${bin}" catalog search-index -c "${catalog}"
+  "${bin}" find -d -c "${catalog}" -p "LTO tapes" txt 2> "${tmpd}/debug.txt" | nocolor > "${out}"
+  cat_file "${out}"
+  grep -q 'search index returned' "${tmpd}/debug.txt" || (echo "search index not used" && exit 1)
+  [ "$(wc -l < "${out}")" != "2" ] && echo "expecting 2 files" && exit 1
+  grep -q '^tape1/dir/one.txt' "${out}" || (echo "one.txt not found" && exit 1)
+  grep -q '^tape2/two.txt' "${out}" || (echo "two.txt not found" && exit 1)
+  if command -v setsid >/dev/null 2>&1; then
+    # no terminal for the finder, only the listing is tested
+    setsid "${bin}" fzfind -d -c "${catalog}" "LTO tapes/2023" < /dev/null > "${out}" 2>&1 || true
+    cat_file "${out}"
+    grep -q 'options contain 2 entries' "${out}" || (echo "bad fzfind entries" && exit 1)
+  fi
+
+  echo ">>> test recursive ls <<<"
+  "${bin}" ls -r -c "${catalog}" | nocolor > "${out}"
+  cat_file "${out}"
+  grep -q 'one.txt' "${out}" || (echo "one.txt not listed" && exit 1)
+  grep -q 'two.txt' "${out}" || (echo "two.txt not listed" && exit 1)
+  grep -q 'three.txt' "${out}" || (echo "three.txt not listed" && exit 1)
+
+  echo ">>> test tree <<<"
+  "${bin}" tree -c "${catalog}" | nocolor > "${out}"
+  cat_file "${out}"
+  grep -q '^collection LTO tapes' "${out}" || (echo "no collection in tree" && exit 1)
+  grep -q 'collection 2023' "${out}" || (echo "no sub collection in tree" && exit 1)
+  grep -q 'storage tape1' "${out}" || (echo "no tape1 in tree" && exit 1)
+  grep -q '^storage disk' "${out}" || (echo "no disk in tree" && exit 1)
+
+  echo ">>> test json <<<"
+  "${bin}" ls -c "${catalog}" -f ndjson | grep '"type":"collection"' | grep -q '"size":10' || (echo "bad collection json" && exit 1)
+
+  echo ">>> test storage move <<<"
+  "${bin}" storage move -c "${catalog}" disk "Disks"
+  "${bin}" ls -c "${catalog}" | nocolor > "${out}"
+  grep -q '^collection Disks' "${out}" || (echo "disk not moved" && exit 1)
+  "${bin}" ls -c "${catalog}" "Disks/disk" | nocolor > "${out}"
+  grep -q '^three.txt' "${out}" || (echo "three.txt not found" && exit 1)
+  "${bin}" storage move -c "${catalog}" tape2
+  "${bin}" ls -c "${catalog}" | nocolor > "${out}"
+  grep -q '^storage tape2' "${out}" || (echo "tape2 not moved out" && exit 1)
+  "${bin}" storage move -c "${catalog}" nope "Disks" && echo "should fail" && exit 1
+
+  echo ">>> test storage rename <<<"
+  "${bin}" find -c "${catalog}" -f ndjson one.txt > "${tmpd}/before.json"
+  "${bin}" storage rename -c "${catalog}" tape1 tape9
+  "${bin}" find -c "${catalog}" -f ndjson one.txt > "${tmpd}/after.json"
+  python3 -c "import json,sys; assert json.load(open(sys.argv[1]))['id'] == json.load(open(sys.argv[2]))['id']" \
+    "${tmpd}/before.json" "${tmpd}/after.json" || (echo "id changed by rename" && exit 1)
+  "${bin}" storage list -c "${catalog}" | nocolor > "${out}"
+  cat_file "${out}"
+  grep -q '^storage tape9 .*collection:LTO tapes/2023' "${out}" || (echo "tape1 not renamed" && exit 1)
+  grep -q 'tape1' "${out}" && echo "tape1 still exists" && exit 1
+  "${bin}" find -c "${catalog}" one.txt | nocolor > "${out}"
+  grep -q '^tape9/dir/one.txt' "${out}" || (echo "one.txt not in renamed storage" && exit 1)
+  "${bin}" storage rename -c "${catalog}" tape9 tape2 && echo "should fail" && exit 1
+  "${bin}" storage rename -c "${catalog}" nope other && echo "should fail" && exit 1
+
+  # re-index keeps the collection
+  "${bin}" index -a -C -f -c "${catalog}" "${tmpd}/one" tape9
+  "${bin}" ls -c "${catalog}" "LTO tapes/2023" | nocolor > "${out}"
+  grep -q '^storage tape9' "${out}" || (echo "collection lost on re-index" && exit 1)
+done
+
+echo "test $(basename "${0}") OK!"
+exit 0
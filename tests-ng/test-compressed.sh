@@ -0,0 +1,54 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test compressed catalogs
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+out="${tmpd}/output.txt"
+expected="${tmpd}/expected.txt"
+
+# reference
+"${bin}" index -a -C -c "${tmpd}/catalog" "${cur}/../internal" internal
+"${bin}" ls -r -a -c "${tmpd}/catalog" | sed -e 's/\x1b\[[0-9;]*m//g' > "${expected}"
+
+for ext in catalog.gz catalog.zst toml.gz json.zst; do
+  echo ">>> test ${ext} <<<"
+  catalog="${tmpd}/catalog.${ext}"
+  "${bin}" index -a -C -c "${catalog}" "${cur}/../internal" internal
+  [ ! -e "${catalog}" ] && echo "catalog not created" && exit 1
+
+  # ensure it is compressed
+  grep -q 'storages' "${catalog}" && echo "catalog not compressed" && exit 1
+
+  "${bin}" ls -r -a -c "${catalog}" | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+  diff "${expected}" "${out}" || (echo "content differ for ${ext}" && exit 1)
+done
+
+# decompress manually
+gzip -dc "${tmpd}/catalog.catalog.gz" > "${tmpd}/decompressed.catalog"
+"${bin}" ls -r -a -c "${tmpd}/decompressed.catalog" | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+diff "${expected}" "${out}" || (echo "content differ for decompressed" && exit 1)
+
+# sqlite catalogs cannot be compressed
+catalog="${tmpd}/catalog.sqlite.gz"
+"${bin}" index -a -C -c "${catalog}" "${cur}/../internal" internal && echo "sqlite compressed" && exit 1
+[ -e "${catalog}" ] && echo "compressed sqlite catalog created" && exit 1
+
+echo "test $(basename "${0}") OK!"
+exit 0
@@ -0,0 +1,92 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test diff command
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+catalog1="${tmpd}/catalog1"
+catalog2="${tmpd}/catalog2"
+out="${tmpd}/output.txt"
+src="${tmpd}/drive"
+other="${tmpd}/other"
+mkdir -p "${src}/sub" "${other}"
+echo "a" > "${src}/a"
+echo "moving content" > "${src}/sub/b"
+echo "c" > "${src}/c"
+echo "o" > "${other}/o"
+
+"${bin}" index -C -c "${catalog1}" "${src}" drive
+"${bin}" index -C -c "${catalog1}" "${other}" other
+cp "${catalog1}" "${catalog2}"
+
+mkdir "${src}/new"
+mv "${src}/sub/b" "${src}/new/b"
+echo "more content" >> "${src}/a"
+rm "${src}/c"
+echo "x" > "${src}/x"
+"${bin}" index -f -C -c "${catalog2}" "${src}" drive
+
+echo ">>> test diff native <<<"
+"${bin}" diff --nocolor "${catalog1}" "${catalog2}" > "${out}"
+cat_file "${out}"
+grep '^storage drive (changed, +' "${out}" || (echo "bad storage" && exit 1)
+grep 'changed *a .*(size,\(maccess,\)\?checksum)' "${out}" || (echo "changed not found" && exit 1)
+grep 'removed *c' "${out}" || (echo "removed not found" && exit 1)
+grep 'added *x' "${out}" || (echo "added not found" && exit 1)
+grep 'moved *sub/b -> new/b' "${out}" || (echo "moved not found" && exit 1)
+grep 'other' "${out}" && (echo "unchanged storage listed" && exit 1)
+
+echo ">>> test diff csv <<<"
+"${bin}" diff -S --format csv "${catalog1}" "${catalog2}" > "${out}"
+cat_file "${out}"
+grep '^drive,removed,c,,-2,$' "${out}" || (echo "bad csv" && exit 1)
+grep '^drive,moved,new/b,sub/b,+0,$' "${out}" || (echo "bad csv" && exit 1)
+
+echo ">>> test diff json <<<"
+"${bin}" diff --format json "${catalog1}" "${catalog2}" > "${out}"
+cat_file "${out}"
+python3 -c "import json,sys; d=json.load(open(sys.argv[1])); assert d[0]['storage'] == 'drive'; assert len(d[0]['changes']) == 5" "${out}"
+
+echo ">>> test diff storage <<<"
+"${bin}" diff --format csv -s other "${catalog1}" "${catalog2}" > "${out}"
+cnt=$(wc -l "${out}" | awk '{print $1}')
+[ "${cnt}" != "0" ] && echo "expecting no difference (got ${cnt})" && exit 1
+
+echo ">>> test diff removed storage <<<"
+"${bin}" -c "${catalog2}" storage rm other --force
+"${bin}" diff -S --format csv "${catalog1}" "${catalog2}" > "${out}"
+cat_file "${out}"
+grep '^other,removed,,,-2,$' "${out}" || (echo "storage removal not found" && exit 1)
+
+echo ">>> test diff backup <<<"
+for ext in catalog catalog.gz catalog.zst db; do
+  current="${tmpd}/backed.${ext}"
+  backup="${tmpd}/backed.bak.1.${ext}"
+  echo "y" > "${src}/y"
+  "${bin}" index -f -C -c "${current}" "${src}" drive
+  rm "${src}/y"
+  "${bin}" index -f -C -c "${current}" "${src}" drive
+  [ ! -e "${backup}" ] && echo "no backup for ${ext}" && exit 1
+  "${bin}" diff -S --format csv "${backup}" "${current}" > "${out}"
+  cat_file "${out}"
+  grep '^drive,removed,y,,-2,$' "${out}" || (echo "bad diff with backup for ${ext}" && exit 1)
+done
+
+echo "test $(basename "${0}") OK!"
+exit 0
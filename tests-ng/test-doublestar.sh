@@ -0,0 +1,67 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test "**" patterns and find full path
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+catalog="${tmpd}/catalog"
+out="${tmpd}/output.txt"
+src="${tmpd}/drive"
+mkdir -p "${src}/a/photos/2019" "${src}/photos/2019" "${src}/b/c/photos/2018"
+touch "${src}/a/photos/2019/x.raw"
+touch "${src}/a/photos/2019/w.jpg"
+touch "${src}/photos/2019/y.raw"
+touch "${src}/b/c/photos/2018/z.raw"
+
+"${bin}" index -c "${catalog}" "${src}" drive
+
+# $1: expected paths (space separated)
+check_out()
+{
+  paths=$(cut -d, -f3 "${out}" | sort | xargs)
+  echo "got: ${paths}"
+  [ "${paths}" != "${1}" ] && echo "expecting \"${1}\"" && exit 1
+  return 0
+}
+
+echo ">>> test ls with ** <<<"
+"${bin}" -c "${catalog}" ls --format csv 'drive/**/photos/2019/*.raw' > "${out}"
+check_out "a/photos/2019/x.raw photos/2019/y.raw"
+"${bin}" -c "${catalog}" ls --format csv '**/*.raw' > "${out}"
+check_out "a/photos/2019/x.raw b/c/photos/2018/z.raw photos/2019/y.raw"
+"${bin}" -c "${catalog}" ls --format csv 'drive/**/nonexistent' > "${out}" && echo "should fail" && exit 1
+
+echo ">>> test du with ** <<<"
+"${bin}" -c "${catalog}" du 'drive/**/2018' > "${out}"
+cat_file "${out}"
+grep 'drive/b/c/photos/2018' "${out}" || (echo "du failed" && exit 1)
+
+echo ">>> test find full path <<<"
+"${bin}" -c "${catalog}" find --full-path --format csv '**/photos/2019/*.raw' > "${out}"
+check_out "a/photos/2019/x.raw photos/2019/y.raw"
+"${bin}" -c "${catalog}" find --full-path --format csv 'drive/*/photos' > "${out}"
+check_out "a/photos"
+"${bin}" -c "${catalog}" find --full-path --format csv '2019/w' > "${out}"
+check_out "a/photos/2019/w.jpg"
+# without full path only the name is matched
+"${bin}" -c "${catalog}" find --format csv '2019/w' > "${out}"
+check_out ""
+
+echo "test $(basename "${0}") OK!"
+exit 0
@@ -0,0 +1,92 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test dupes command
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+catalog="${tmpd}/catalog"
+out="${tmpd}/output.txt"
+src1="${tmpd}/drive1"
+src2="${tmpd}/drive2"
+mkdir -p "${src1}/sub" "${src2}"
+echo "same" > "${src1}/a"
+echo "same" > "${src1}/sub/a-copy"
+echo "same" > "${src2}/a-other"
+echo "big content duplicated on one drive" > "${src1}/big"
+echo "big content duplicated on one drive" > "${src1}/sub/big"
+echo "only on drive1" > "${src1}/unique1"
+echo "only on drive2" > "${src2}/unique2"
+touch "${src1}/empty1" "${src1}/sub/empty2" "${src2}/empty3"
+
+"${bin}" index -C -c "${catalog}" "${src1}" drive1
+"${bin}" index -C -c "${catalog}" "${src2}" drive2
+
+echo ">>> test dupes <<<"
+"${bin}" -c "${catalog}" dupes --nocolor | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+cat_file "${out}"
+grep '^3 copies of' "${out}" || (echo "bad group" && exit 1)
+grep '^2 copies of' "${out}" || (echo "bad group" && exit 1)
+# sorted by wasted space
+head -1 "${out}" | grep '^2 copies of' || (echo "bad sort" && exit 1)
+grep 'unique' "${out}" && (echo "unique files listed" && exit 1)
+grep 'empty' "${out}" && (echo "empty files listed" && exit 1)
+
+echo ">>> test dupes csv <<<"
+"${bin}" -c "${catalog}" dupes --format csv > "${out}"
+cat_file "${out}"
+cnt=$(wc -l "${out}" | awk '{print $1}')
+[ "${cnt}" != "5" ] && echo "expecting 5 duplicates (got ${cnt})" && exit 1
+
+echo ">>> test dupes cross storage <<<"
+"${bin}" -c "${catalog}" dupes -x --format csv > "${out}"
+cat_file "${out}"
+cnt=$(wc -l "${out}" | awk '{print $1}')
+[ "${cnt}" != "3" ] && echo "expecting 3 duplicates (got ${cnt})" && exit 1
+grep 'big' "${out}" && (echo "single storage duplicate listed" && exit 1)
+
+echo ">>> test dupes in a storage <<<"
+"${bin}" -c "${catalog}" dupes 'drive2' --format csv > "${out}"
+cnt=$(wc -l "${out}" | awk '{print $1}')
+[ "${cnt}" != "0" ] && echo "expecting no duplicates (got ${cnt})" && exit 1
+
+echo ">>> test unique <<<"
+"${bin}" -c "${catalog}" dupes -u --format csv > "${out}"
+cat_file "${out}"
+grep '^unique1,' "${out}" || (echo "unique1 not found" && exit 1)
+grep '^unique2,' "${out}" || (echo "unique2 not found" && exit 1)
+grep '^big,' "${out}" || (echo "big not found" && exit 1)
+grep '^a' "${out}" && (echo "duplicated file listed" && exit 1)
+grep '^empty' "${out}" && (echo "empty file listed" && exit 1)
+
+echo ">>> test mixed checksum algorithms <<<"
+"${bin}" index -C -c "${tmpd}/mixed" "${src1}" drive1
+"${bin}" index --checksum-algo sha256 -c "${tmpd}/mixed" "${src2}" drive2
+"${bin}" -c "${tmpd}/mixed" dupes -u --format csv > "${out}" 2> "${tmpd}/err.txt"
+cat_file "${out}"
+grep 'different checksum algorithms' "${tmpd}/err.txt" || (echo "no warning" && exit 1)
+grep '^a-other,' "${out}" && (echo "not comparable file listed as unique" && exit 1)
+grep '^big,' "${out}" || (echo "big not found" && exit 1)
+
+echo ">>> test without checksum <<<"
+"${bin}" index -c "${tmpd}/nochecksum" "${src1}" drive1
+"${bin}" -c "${tmpd}/nochecksum" dupes 2> "${out}"
+grep 'without checksum' "${out}" || (echo "no warning" && exit 1)
+
+echo "test $(basename "${0}") OK!"
+exit 0
@@ -0,0 +1,72 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test export html
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+catalog="${tmpd}/catalog"
+src="${tmpd}/src"
+web="${tmpd}/web"
+
+mkdir -p "${src}/dir/sub"
+echo "abc" > "${src}/dir/a.txt"
+echo "defgh" > "${src}/dir/sub/b<&>.txt"
+echo "ijk" > "${src}/c.txt"
+echo "hidden" > "${src}/.hidden"
+
+"${bin}" index -a -C -c "${catalog}" "${src}" mystorage --meta "external drive" --tag backup
+[ ! -e "${catalog}" ] && echo "catalog not created" && exit 1
+
+echo ">>> test export html <<<"
+"${bin}" export html -c "${catalog}" "${web}"
+for f in index.html style.css search.js search-data.js; do
+  [ ! -e "${web}/${f}" ] && echo "${f} not created" && exit 1
+done
+# storage, dir and dir/sub
+cnt=$(find "${web}/pages" -type f | wc -l)
+[ "${cnt}" != "3" ] && echo "expecting 3 pages (${cnt})" && exit 1
+
+# index lists the storage
+grep -q 'href="pages/0.html">mystorage</a>' "${web}/index.html" || (echo "storage not in index" && exit 1)
+
+# storage summary
+grep -q '<th>meta</th><td>external drive</td>' "${web}/pages/0.html" || (echo "meta not in summary" && exit 1)
+grep -q '<th>tags</th><td>backup</td>' "${web}/pages/0.html" || (echo "tags not in summary" && exit 1)
+grep -q '<th>files</th><td>4</td>' "${web}/pages/0.html" || (echo "nbfiles not in summary" && exit 1)
+
+# directory page with breadcrumbs and escaped names
+page=$(grep -l '<h2>mystorage/dir/sub</h2>' "${web}"/pages/*.html)
+grep -q '<a href="0.html">mystorage</a> / <a href="1.html">dir</a> / <a href="2.html">sub</a>' "${page}" || (echo "bad breadcrumbs" && exit 1)
+grep -q 'b&lt;&amp;&gt;.txt' "${page}" || (echo "name not escaped" && exit 1)
+
+# hidden files are skipped
+grep -q '.hidden' "${web}/search-data.js" && echo "hidden file exported" && exit 1
+grep -q '"mystorage/dir/a.txt",1,"file"' "${web}/search-data.js" || (echo "bad search data" && exit 1)
+
+echo ">>> test export html non empty <<<"
+"${bin}" export html -c "${catalog}" "${web}" && echo "should fail on non-empty dir" && exit 1
+
+echo ">>> test export html subtree with hidden <<<"
+"${bin}" export html -a -f -c "${catalog}" "${web}" mystorage/dir
+cnt=$(find "${web}/pages" -type f | wc -l)
+[ "${cnt}" != "2" ] && echo "expecting 2 pages (${cnt})" && exit 1
+grep -q 'href="pages/0.html">mystorage/dir</a>' "${web}/index.html" || (echo "subtree not in index" && exit 1)
+
+echo "test $(basename "${0}") OK!"
+exit 0
@@ -0,0 +1,127 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test using multiple catalogs at once
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+catalogs="${tmpd}/catalogs"
+home="${catalogs}/home.catalog"
+office="${catalogs}/office.catalog.gz"
+out="${tmpd}/output.txt"
+
+mkdir -p "${catalogs}" "${tmpd}/home/dir" "${tmpd}/office/dir"
+echo "abc" > "${tmpd}/home/dir/home.txt"
+echo "defgh" > "${tmpd}/office/dir/office.txt"
+
+# same storage name in both catalogs
+"${bin}" index -a -C -c "${home}" "${tmpd}/home" photos
+"${bin}" index -a -C -c "${office}" "${tmpd}/office" photos
+# not catalogs
+echo "something" > "${catalogs}/README"
+"${bin}" catalog search-index -c "${home}"
+
+echo ">>> test ls multiple catalogs <<<"
+"${bin}" ls -c "${home}" -c "${office}" | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+cat_file "${out}"
+grep -q 'home:photos' "${out}" || (echo "home storage not found" && exit 1)
+grep -q 'office:photos' "${out}" || (echo "office storage not found" && exit 1)
+
+echo ">>> test ls directory of catalogs <<<"
+"${bin}" ls -c "${catalogs}" | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+cat_file "${out}"
+cnt=$(wc -l "${out}" | awk '{print $1}')
+[ "${cnt}" != "2" ] && echo "expecting 2 storages (${cnt})" && exit 1
+
+echo ">>> test ls path <<<"
+"${bin}" ls -c "${catalogs}" office:photos/dir | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+cat_file "${out}"
+grep -q 'office.txt' "${out}" || (echo "office.txt not found" && exit 1)
+
+echo ">>> test find <<<"
+"${bin}" find -f csv -c "${catalogs}" txt | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+cat_file "${out}"
+grep -q 'home.txt' "${out}" || (echo "home.txt not found" && exit 1)
+grep -q 'office.txt' "${out}" || (echo "office.txt not found" && exit 1)
+
+echo ">>> test find json <<<"
+"${bin}" find -f ndjson -c "${catalogs}" office > "${out}"
+cat_file "${out}"
+grep -q '"storage":"office:photos"' "${out}" || (echo "bad storage name" && exit 1)
+
+echo ">>> test tree and du <<<"
+"${bin}" tree -c "${catalogs}" | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+cat_file "${out}"
+grep -q 'home.txt' "${out}" || (echo "home.txt not in tree" && exit 1)
+grep -q 'office.txt' "${out}" || (echo "office.txt not in tree" && exit 1)
+"${bin}" du -S -c "${catalogs}" | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+cat_file "${out}"
+grep -q 'office:photos/dir' "${out}" || (echo "office dir not in du" && exit 1)
+
+echo ">>> test ids unchanged <<<"
+"${bin}" find -f ndjson -c "${office}" office.txt > "${out}"
+cat_file "${out}"
+id=$(python3 -c "import json,sys; print(json.loads(open(sys.argv[1]).readline())['id'])" "${out}")
+"${bin}" find -f ndjson -c "${catalogs}" office.txt > "${out}"
+cat_file "${out}"
+fid=$(python3 -c "import json,sys; print(json.loads(open(sys.argv[1]).readline())['id'])" "${out}")
+[ "${id}" != "${fid}" ] && echo "id changed by federation" && exit 1
+
+echo ">>> test same storage in both catalogs <<<"
+echo "same" > "${tmpd}/home/dir/same.txt"
+echo "same" > "${tmpd}/office/dir/same.txt"
+"${bin}" index -f -a -C -c "${home}" "${tmpd}/home" photos
+"${bin}" index -f -a -C -c "${office}" "${tmpd}/office" photos
+"${bin}" dupes -x -f csv -c "${catalogs}" | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+cat_file "${out}"
+grep -q '^same.txt,.*,home:photos$' "${out}" || (echo "home duplicate not found" && exit 1)
+grep -q '^same.txt,.*,office:photos$' "${out}" || (echo "office duplicate not found" && exit 1)
+
+echo ">>> test sqlite catalogs loaded lazily <<<"
+sqlite="${catalogs}/archive.sqlite"
+"${bin}" index -a -C -c "${sqlite}" "${tmpd}/home" photos
+"${bin}" ls -d -c "${catalogs}" archive:photos/dir > "${out}" 2>&1
+cat_file "${out}"
+grep -q 'loading catalog lazily' "${out}" || (echo "sqlite catalog not loaded lazily" && exit 1)
+grep -q 'same.txt' "${out}" || (echo "same.txt not found" && exit 1)
+rm "${sqlite}"
+
+echo ">>> test catalogs unchanged <<<"
+"${bin}" ls -c "${home}" | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+grep -q 'home:' "${out}" && echo "catalog modified" && exit 1
+
+echo ">>> test catalogs from the env <<<"
+GOCATCLI_CATALOG="${home}:${office}" "${bin}" ls | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+cat_file "${out}"
+grep -q 'home:photos' "${out}" || (echo "home storage not found" && exit 1)
+grep -q 'office:photos' "${out}" || (echo "office storage not found" && exit 1)
+
+echo ">>> test comma in the catalog path <<<"
+comma="${tmpd}/my,cat.catalog"
+"${bin}" index -a -C -c "${comma}" "${tmpd}/home" photos
+[ ! -e "${comma}" ] && echo "catalog with a comma not created" && exit 1
+"${bin}" ls -c "${comma}" | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+cat_file "${out}"
+grep -q 'storage photos' "${out}" || (echo "photos not found" && exit 1)
+
+echo ">>> test write commands refused <<<"
+"${bin}" storage meta -c "${catalogs}" home:photos "meta" && echo "should fail" && exit 1
+"${bin}" index -a -C -c "${catalogs}" "${tmpd}/home" other && echo "should fail" && exit 1
+
+echo "test $(basename "${0}") OK!"
+exit 0
@@ -0,0 +1,157 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test content indexing and grep
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+out="${tmpd}/output.txt"
+src="${tmpd}/src"
+
+# strip colors
+nocolor() {
+  sed -e 's/\x1b\[[0-9;]*m//g'
+}
+
+# create the files to index
+populate() {
+  rm -rf "${src}"
+  mkdir -p "${src}/notes" "${src}/code"
+  "${cur}/media.py" "${src}/docs"
+  printf 'groceries\nbuy some Quinoa and apples\n' > "${src}/notes/todo.md"
+  printf 'the quinoa harvest\n' > "${src}/notes/farm.txt"
+  printf 'package main\n\nfunc main() {\n\tprintln("hello quinoa")\n}\n' > "${src}/code/main.go"
+  head -c 4096 /dev/zero > "${src}/blob.bin"
+  # larger than the limit
+  for _ in $(seq 1 200); do echo "a line about quinoa and more"; done > "${src}/notes/big.txt"
+}
+
+for catalog in "${tmpd}/catalog.json" "${tmpd}/catalog.sqlite"; do
+  echo ">>> testing with \"$(basename "${catalog}")\" <<<"
+  populate
+
+  echo ">>> test no full-text index <<<"
+  "${bin}" index -c "${catalog}" "${src}" drive
+  [ -e "${catalog}.fts" ] && echo "full-text index created without --content" && exit 1
+  "${bin}" grep -c "${catalog}" quinoa && echo "grep should fail without full-text index" && exit 1
+
+  echo ">>> test content indexing <<<"
+  "${bin}" index --content --content-limit 1K -f -c "${catalog}" "${src}" drive
+  [ ! -e "${catalog}.fts" ] && echo "no full-text index" && exit 1
+
+  # offline
+  mv "${src}" "${src}.offline"
+
+  echo ">>> test grep <<<"
+  "${bin}" grep -c "${catalog}" quinoa | nocolor > "${out}"
+  cat_file "${out}"
+  [ "$(wc -l < "${out}")" != "2" ] && echo "expecting 2 matches" && exit 1
+  grep -q '^drive/notes/farm.txt:1:the quinoa harvest$' "${out}" || (echo "txt not found" && exit 1)
+  grep -q '^drive/code/main.go:4:println("hello quinoa")$' "${out}" || (echo "source not found" && exit 1)
+  grep -q 'big.txt' "${out}" && echo "file larger than the limit indexed" && exit 1
+
+  echo ">>> test grep ignore case <<<"
+  "${bin}" grep -c "${catalog}" -i quinoa | nocolor > "${out}"
+  cat_file "${out}"
+  [ "$(wc -l < "${out}")" != "3" ] && echo "expecting 3 matches" && exit 1
+  grep -q '^drive/notes/todo.md:2:buy some Quinoa and apples$' "${out}" || (echo "markdown not found" && exit 1)
+
+  echo ">>> test grep documents <<<"
+  "${bin}" grep -c "${catalog}" "twelve percent" | nocolor > "${out}"
+  cat_file "${out}"
+  grep -q '^drive/docs/report.pdf:[0-9]*:by twelve percent$' "${out}" || (echo "pdf not found" && exit 1)
+  "${bin}" grep -c "${catalog}" "approved" | nocolor > "${out}"
+  cat_file "${out}"
+  grep -q '^drive/docs/minutes.docx:2:The budget was approved unanimously$' "${out}" || (echo "docx not found" && exit 1)
+
+  echo ">>> test grep regex, files only and path <<<"
+  "${bin}" grep -c "${catalog}" -E -l 'q[a-z]+a h' | nocolor > "${out}"
+  cat_file "${out}"
+  [ "$(cat "${out}")" != "drive/notes/farm.txt" ] && echo "bad regex match" && exit 1
+  "${bin}" grep -c "${catalog}" -i -l quinoa drive/notes | nocolor > "${out}"
+  cat_file "${out}"
+  [ "$(wc -l < "${out}")" != "2" ] && echo "expecting 2 files under notes" && exit 1
+  grep -q 'main.go' "${out}" && echo "path not honored" && exit 1
+  "${bin}" grep -c "${catalog}" nothingmatchesthis | nocolor > "${out}"
+  [ -s "${out}" ] && echo "unexpected match" && exit 1
+
+  mv "${src}.offline" "${src}"
+
+  echo ">>> test incremental keeps content <<<"
+  echo "quinoa salad" >> "${src}/notes/todo.md"
+  "${bin}" index --content --content-limit 1K -u -f -c "${catalog}" "${src}" drive
+  "${bin}" grep -c "${catalog}" -l quinoa | nocolor > "${out}"
+  cat_file "${out}"
+  [ "$(wc -l < "${out}")" != "3" ] && echo "expecting 3 files after update" && exit 1
+  grep -q 'todo.md' "${out}" || (echo "changed file not re-indexed" && exit 1)
+
+  echo ">>> test removed files are dropped <<<"
+  rm "${src}/notes/farm.txt"
+  "${bin}" index --content --content-limit 1K -u -f -c "${catalog}" "${src}" drive
+  "${bin}" grep -c "${catalog}" harvest | nocolor > "${out}"
+  [ -s "${out}" ] && echo "removed file still found" && exit 1
+
+  echo ">>> test re-index without content <<<"
+  # like checksums, only the text of the files that changed is dropped
+  echo "// quinoa" >> "${src}/code/main.go"
+  "${bin}" index -f -c "${catalog}" "${src}" drive
+  "${bin}" grep -c "${catalog}" -l quinoa | nocolor > "${out}"
+  cat_file "${out}"
+  grep -q 'main.go' "${out}" && echo "text of changed file kept" && exit 1
+  grep -q 'todo.md' "${out}" || (echo "text of unchanged file lost" && exit 1)
+
+  echo ">>> test renamed storage <<<"
+  "${bin}" storage rename -c "${catalog}" drive renamed
+  "${bin}" grep -c "${catalog}" -l apples | nocolor > "${out}"
+  cat_file "${out}"
+  [ "$(cat "${out}")" != "renamed/notes/todo.md" ] && echo "content lost on rename" && exit 1
+  # a new storage with the previous name
+  "${bin}" index --content -f -c "${catalog}" "${src}/notes" drive
+  "${bin}" grep -c "${catalog}" -l apples | nocolor | sort > "${out}"
+  cat_file "${out}"
+  [ "$(xargs < "${out}")" != "drive/todo.md renamed/notes/todo.md" ] && echo "bad content after rename" && exit 1
+  "${bin}" storage rm -f -c "${catalog}" drive
+  "${bin}" storage rename -c "${catalog}" renamed drive
+  "${bin}" grep -c "${catalog}" -l apples | nocolor > "${out}"
+  [ "$(cat "${out}")" != "drive/notes/todo.md" ] && echo "content lost on rename" && exit 1
+
+  echo ">>> test removed storage <<<"
+  "${bin}" storage rm -f -c "${catalog}" drive
+  "${bin}" grep -c "${catalog}" -l quinoa | nocolor > "${out}"
+  [ -s "${out}" ] && echo "removed storage still found" && exit 1
+done
+
+echo ">>> test grep multiple catalogs <<<"
+# same storage and path in both catalogs
+mkdir -p "${tmpd}/home/notes" "${tmpd}/office/notes"
+echo "alpha quinoa" > "${tmpd}/home/notes/x.txt"
+echo "beta quinoa" > "${tmpd}/office/notes/x.txt"
+echo "gamma" > "${tmpd}/office/notes/y.txt"
+"${bin}" index --content -c "${tmpd}/home.catalog" "${tmpd}/home" drive
+"${bin}" index --content -c "${tmpd}/office.sqlite" "${tmpd}/office" drive
+"${bin}" grep -c "${tmpd}/home.catalog" -c "${tmpd}/office.sqlite" quinoa | nocolor | sort > "${out}"
+cat_file "${out}"
+[ "$(wc -l < "${out}")" != "2" ] && echo "expecting 2 matches" && exit 1
+grep -q '^home:drive/notes/x.txt:1:alpha quinoa$' "${out}" || (echo "home match not found" && exit 1)
+grep -q '^office:drive/notes/x.txt:1:beta quinoa$' "${out}" || (echo "office match not found" && exit 1)
+"${bin}" grep -c "${tmpd}/home.catalog" -c "${tmpd}/office.sqlite" gamma office:drive | nocolor > "${out}"
+cat_file "${out}"
+grep -q '^office:drive/notes/y.txt:1:gamma$' "${out}" || (echo "office match not found with path" && exit 1)
+
+echo "test $(basename "${0}") OK!"
+exit 0
@@ -0,0 +1,59 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test stable node ids
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+catalog="${tmpd}/catalog.json"
+ids1="${tmpd}/ids1.txt"
+ids2="${tmpd}/ids2.txt"
+
+get_ids()
+{
+  grep -o '"id": [^,]*' "${1}" | sort
+}
+
+echo ">>> test ids set <<<"
+"${bin}" index -c "${catalog}" "${cur}/../internal" internal
+grep '"id": ""' "${catalog}" && (echo "empty ids" && exit 1)
+get_ids "${catalog}" > "${ids1}"
+cnt=$(sort -u "${ids1}" | wc -l)
+# find also lists the storage itself
+expected=$(find "${cur}/../internal" | wc -l)
+[ "${cnt}" != "${expected}" ] && echo "expecting ${expected} ids (got ${cnt})" && exit 1
+
+echo ">>> test ids stable on re-index <<<"
+"${bin}" index -f -c "${catalog}" "${cur}/../internal" internal
+get_ids "${catalog}" > "${ids2}"
+diff "${ids1}" "${ids2}" || (echo "ids changed" && exit 1)
+
+echo ">>> test ids stable on re-create <<<"
+"${bin}" -c "${catalog}" storage rm -f internal
+"${bin}" index -c "${catalog}" "${cur}/../internal" internal
+get_ids "${catalog}" > "${ids2}"
+diff "${ids1}" "${ids2}" || (echo "ids changed" && exit 1)
+
+echo ">>> test ids of legacy catalog <<<"
+sed -i -e 's/"id": "[^"]*"/"id": ""/g' -e 's/"id": [0-9][0-9]*/"id": 1/g' -e 's/"storage_id": [0-9][0-9]*/"storage_id": 1/g' -e '/"schema":/d' "${catalog}"
+"${bin}" -c "${catalog}" catalog upgrade
+get_ids "${catalog}" > "${ids2}"
+diff "${ids1}" "${ids2}" || (echo "ids not migrated" && exit 1)
+
+echo "test $(basename "${0}") OK!"
+exit 0
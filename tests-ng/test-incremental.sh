@@ -0,0 +1,72 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test incremental re-index
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+catalog="${tmpd}/catalog"
+out="${tmpd}/output.txt"
+src="${tmpd}/src"
+mkdir -p "${src}/sub"
+echo "aaaa" > "${src}/a"
+echo "bbbb" > "${src}/sub/b"
+echo "cccc" > "${src}/c"
+tar -C "${cur}/.." -czf "${src}/archive.tar.gz" "internal"
+cnt_archive=$(tar -tzf "${src}/archive.tar.gz" | wc -l)
+
+echo ">>> test initial index <<<"
+"${bin}" index -C -a -u -c "${catalog}" "${src}" src > "${out}"
+cat_file "${out}"
+grep '5 added, 0 modified, 0 removed, 0 unchanged' "${out}" || (echo "bad stats" && exit 1)
+
+echo ">>> test incremental re-index <<<"
+# same size and mtime, the checksum is reused
+cp -p "${src}/a" "${tmpd}/a"
+echo "AAAA" > "${src}/a"
+touch -r "${tmpd}/a" "${src}/a"
+# modified
+echo "bbbbbb" > "${src}/sub/b"
+touch -d '2000-01-01' "${src}/sub/b"
+# removed and added
+rm "${src}/c"
+echo "dddd" > "${src}/d"
+
+"${bin}" index -C -a -u -f -c "${catalog}" "${src}" src > "${out}"
+cat_file "${out}"
+grep '1 added, 1 modified, 1 removed, 3 unchanged' "${out}" || (echo "bad stats" && exit 1)
+
+"${bin}" -c "${catalog}" ls -r -a --format csv > "${out}"
+cat_file "${out}"
+grep "^a,.*,md5:$(md5sum "${tmpd}/a" | awk '{print $1}')," "${out}" || (echo "checksum not reused" && exit 1)
+grep "^b,.*,md5:$(md5sum "${src}/sub/b" | awk '{print $1}')," "${out}" || (echo "checksum not updated" && exit 1)
+grep "^d,.*,md5:$(md5sum "${src}/d" | awk '{print $1}')," "${out}" || (echo "no checksum for new file" && exit 1)
+grep '^c,' "${out}" && (echo "removed file still indexed" && exit 1)
+# archive content kept
+cnt=$(grep -c ',archived,' "${out}")
+[ "${cnt}" != "${cnt_archive}" ] && echo "expecting ${cnt_archive} archived (got ${cnt})" && exit 1
+
+echo ">>> test full re-index <<<"
+"${bin}" index -C -a -f -c "${catalog}" "${src}" src
+"${bin}" -c "${catalog}" ls -r -a --format csv > "${out}"
+grep "^a,.*,md5:$(md5sum "${src}/a" | awk '{print $1}')," "${out}" || (echo "checksum not updated" && exit 1)
+cnt=$(grep -c ',archived,' "${out}")
+[ "${cnt}" != "${cnt_archive}" ] && echo "expecting ${cnt_archive} archived (got ${cnt})" && exit 1
+
+echo "test $(basename "${0}") OK!"
+exit 0
@@ -0,0 +1,99 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test json and ndjson output formats
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+catalog="${tmpd}/catalog"
+out="${tmpd}/output.txt"
+src="${tmpd}/src"
+
+mkdir -p "${src}/dir/sub"
+echo "abc" > "${src}/dir/a.txt"
+echo "defgh" > "${src}/dir/sub/b.txt"
+echo "ijk" > "${src}/c.txt"
+
+"${bin}" index -a -C -c "${catalog}" "${src}" mystorage
+[ ! -e "${catalog}" ] && echo "catalog not created" && exit 1
+
+# check_json <file> <expected count> <python condition on entries>
+check_json() {
+  python3 - "${1}" "${2}" "${3}" <<'PYEOF'
+import json, sys
+path, cnt, cond = sys.argv[1], int(sys.argv[2]), sys.argv[3]
+with open(path) as f:
+    content = f.read()
+if path.endswith(".ndjson"):
+    entries = [json.loads(line) for line in content.splitlines() if line]
+else:
+    entries = json.loads(content)
+if len(entries) != cnt:
+    print(f"expecting {cnt} entries, got {len(entries)}")
+    sys.exit(1)
+if not eval(cond):
+    print(f"condition failed: {cond}")
+    sys.exit(1)
+PYEOF
+}
+
+echo ">>> test ls json <<<"
+"${bin}" ls -r -f json -c "${catalog}" > "${out}"
+cat "${out}"
+check_json "${out}" 6 "any(e['path'] == 'dir/sub/b.txt' and e['size'] == 6 and e['storage'] == 'mystorage' and e['checksum'].startswith('md5:') for e in entries)"
+
+echo ">>> test ls ndjson <<<"
+"${bin}" ls -r -f ndjson -c "${catalog}" > "${out}.ndjson"
+cat "${out}.ndjson"
+check_json "${out}.ndjson" 6 "entries[0]['type'] == 'storage' and entries[0]['name'] == 'mystorage'"
+
+echo ">>> test find json <<<"
+"${bin}" find -f json -c "${catalog}" txt > "${out}"
+cat "${out}"
+check_json "${out}" 3 "all(e['type'] == 'file' for e in entries)"
+
+echo ">>> test find json with multiple patterns <<<"
+"${bin}" find -f json -c "${catalog}" a.txt b.txt > "${out}"
+check_json "${out}" 2 "sorted(e['name'] for e in entries) == ['a.txt', 'b.txt']"
+
+echo ">>> test find json no match <<<"
+"${bin}" find -f json -c "${catalog}" doesnotexist > "${out}"
+check_json "${out}" 0 "True"
+
+echo ">>> test tree json <<<"
+"${bin}" tree -f json -c "${catalog}" mystorage/dir > "${out}"
+cat "${out}"
+check_json "${out}" 4 "entries[0]['path'] == 'dir'"
+
+echo ">>> test du ndjson <<<"
+"${bin}" du -f ndjson -c "${catalog}" > "${out}.ndjson"
+cat "${out}.ndjson"
+check_json "${out}.ndjson" 3 "entries[-1]['type'] == 'storage' and entries[-1]['size'] == 14"
+
+echo ">>> test dupes json <<<"
+echo "abc" > "${src}/dupe.txt"
+"${bin}" index -a -C -f -c "${catalog}" "${src}" mystorage
+"${bin}" dupes -f json -c "${catalog}" > "${out}"
+cat "${out}"
+check_json "${out}" 2 "sorted(e['name'] for e in entries) == ['a.txt', 'dupe.txt']"
+
+echo ">>> test bad format <<<"
+"${bin}" tree -f csv -c "${catalog}" && echo "tree csv should fail" && exit 1
+
+echo "test $(basename "${0}") OK!"
+exit 0
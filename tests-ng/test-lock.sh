@@ -0,0 +1,78 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test catalog locking
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+catalog="${tmpd}/catalog"
+out="${tmpd}/output.txt"
+
+echo ">>> test concurrent index <<<"
+"${bin}" index -c "${catalog}" "${cur}/../internal" internal &
+pid1=$!
+"${bin}" index -c "${catalog}" "${cur}/../tests-ng" testsng &
+pid2=$!
+"${bin}" index -c "${catalog}" "${cur}/../cmd" cmd &
+pid3=$!
+wait "${pid1}" "${pid2}" "${pid3}"
+
+"${bin}" -c "${catalog}" storage list | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+cat_file "${out}"
+cnt=$(wc -l "${out}" | awk '{print $1}')
+[ "${cnt}" != "3" ] && echo "expecting 3 storages (got ${cnt})" && exit 1
+
+[ -e "${catalog}.lock" ] && echo "lock file left" && exit 1
+
+if command -v flock >/dev/null 2>&1; then
+  echo ">>> test waiting for lock <<<"
+  flock "${catalog}.lock" sleep 2 &
+  sleep 0.5
+  "${bin}" -c "${catalog}" ls 2> "${out}" >/dev/null
+  cat_file "${out}"
+  grep 'is in use' "${out}" || (echo "lock not honored" && exit 1)
+
+  # shared locks do not block each other
+  flock -s "${catalog}.lock" sleep 2 &
+  sleep 0.5
+  "${bin}" -c "${catalog}" ls 2> "${out}" >/dev/null
+  grep 'is in use' "${out}" && (echo "shared lock blocked" && exit 1)
+  wait
+
+  # the lock file removed while waiting is not used
+  flock "${catalog}.lock" sh -c "sleep 1; rm -f '${catalog}.lock'" &
+  sleep 0.5
+  "${bin}" -c "${catalog}" storage tag testsng waited 2> "${out}"
+  cat_file "${out}"
+  grep 'is in use' "${out}" || (echo "lock not honored" && exit 1)
+  wait
+  [ -e "${catalog}.lock" ] && echo "lock file left" && exit 1
+fi
+
+echo ">>> test read-only directory <<<"
+if [ "$(id -u)" != "0" ]; then
+  # root ignores the permissions
+  chmod a-w "${tmpd}"
+  "${bin}" -c "${catalog}" ls > "${out}" || (chmod u+w "${tmpd}" && echo "read-only catalog not readable" && exit 1)
+  "${bin}" -c "${catalog}" storage tag testsng ro > /dev/null 2>&1 && chmod u+w "${tmpd}" && echo "read-only catalog modified" && exit 1
+  chmod u+w "${tmpd}"
+  cat_file "${out}"
+fi
+
+echo "test $(basename "${0}") OK!"
+exit 0
@@ -0,0 +1,103 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test catalog merge and extract
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+cata="${tmpd}/a.catalog"
+catb="${tmpd}/b.catalog.gz"
+merged="${tmpd}/merged.catalog"
+out="${tmpd}/output.txt"
+
+mkdir -p "${tmpd}/one" "${tmpd}/two" "${tmpd}/three"
+echo "abc" > "${tmpd}/one/one.txt"
+echo "defgh" > "${tmpd}/two/two.txt"
+echo "ijk" > "${tmpd}/three/three.txt"
+
+"${bin}" index -a -C -c "${cata}" "${tmpd}/one" drive1
+"${bin}" index -a -C -c "${cata}" "${tmpd}/two" drive2
+# drive1 in both catalogs
+"${bin}" index -a -C -c "${catb}" "${tmpd}/three" drive1
+
+# storages <catalog>
+storages() {
+  "${bin}" storage list -c "${1}" | sed -e 's/\x1b\[[0-9;]*m//g' | awk '{print $2}' | sort | xargs
+}
+
+echo ">>> test merge rename <<<"
+"${bin}" catalog merge "${cata}" "${catb}" -o "${merged}"
+[ "$(storages "${merged}")" != "drive1 drive1-2 drive2" ] && echo "bad storages: $(storages "${merged}")" && exit 1
+"${bin}" find -c "${merged}" three | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+cat_file "${out}"
+grep -q 'three.txt' "${out}" || (echo "three.txt not found" && exit 1)
+"${bin}" ls -c "${merged}" drive1-2 | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+grep -q 'three.txt' "${out}" || (echo "three.txt not in renamed storage" && exit 1)
+
+echo ">>> test merge skip <<<"
+"${bin}" catalog merge -f -p skip "${cata}" "${catb}" -o "${merged}"
+[ "$(storages "${merged}")" != "drive1 drive2" ] && echo "bad storages: $(storages "${merged}")" && exit 1
+"${bin}" ls -c "${merged}" drive1 | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+grep -q 'one.txt' "${out}" || (echo "drive1 replaced" && exit 1)
+
+echo ">>> test merge replace <<<"
+"${bin}" catalog merge -f -p replace "${cata}" "${catb}" -o "${merged}"
+[ "$(storages "${merged}")" != "drive1 drive2" ] && echo "bad storages: $(storages "${merged}")" && exit 1
+"${bin}" ls -c "${merged}" drive1 | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+grep -q 'three.txt' "${out}" || (echo "drive1 not replaced" && exit 1)
+
+echo ">>> test merge collisions by name and id <<<"
+# "bar" keeps the id of "foo" it was renamed from
+colla="${tmpd}/colla.json"
+collb="${tmpd}/collb.json"
+"${bin}" index -a -C -c "${colla}" "${tmpd}/one" foo
+"${bin}" storage rename -c "${colla}" foo bar
+"${bin}" index -a -C -c "${colla}" "${tmpd}/two" foo
+"${bin}" index -a -C -c "${collb}" "${tmpd}/three" foo
+for policy in rename skip replace; do
+  for ext in json sqlite; do
+    collm="${tmpd}/collm-${policy}.${ext}"
+    "${bin}" catalog merge -f -p "${policy}" "${colla}" "${collb}" -o "${collm}"
+    names=$(storages "${collm}")
+    [ "$(echo "${names}" | tr ' ' '\n' | sort -u | xargs)" != "${names}" ] && echo "duplicate names with ${policy}: ${names}" && exit 1
+    echo "${names}" | grep -q 'bar' || (echo "bar lost with ${policy}" && exit 1)
+    "${bin}" ls -r -c "${collm}" bar | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+    grep -q 'one.txt' "${out}" || (echo "bar content lost with ${policy}" && exit 1)
+  done
+  python3 -c "import json,sys; ids=[s['id'] for s in json.load(open(sys.argv[1]))['storages']]; assert len(ids) == len(set(ids)), ids" "${tmpd}/collm-${policy}.json"
+done
+"${bin}" ls -c "${tmpd}/collm-replace.sqlite" foo | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+grep -q 'three.txt' "${out}" || (echo "foo not replaced" && exit 1)
+
+echo ">>> test merge bad policy <<<"
+"${bin}" catalog merge -f -p bad "${cata}" "${catb}" -o "${merged}" && echo "should fail" && exit 1
+
+echo ">>> test extract <<<"
+extracted="${tmpd}/extracted.json"
+"${bin}" catalog extract -c "${cata}" drive2 -o "${extracted}"
+[ "$(storages "${extracted}")" != "drive2" ] && echo "bad storages: $(storages "${extracted}")" && exit 1
+"${bin}" find -c "${extracted}" two | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+grep -q 'two.txt' "${out}" || (echo "two.txt not found" && exit 1)
+# source untouched
+[ "$(storages "${cata}")" != "drive1 drive2" ] && echo "source modified" && exit 1
+
+echo ">>> test extract unknown storage <<<"
+"${bin}" catalog extract -f -c "${cata}" nope -o "${extracted}" && echo "should fail" && exit 1
+
+echo "test $(basename "${0}") OK!"
+exit 0
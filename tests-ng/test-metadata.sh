@@ -0,0 +1,93 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test metadata extraction
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+out="${tmpd}/output.txt"
+src="${tmpd}/src"
+
+"${cur}/media.py" "${src}"
+echo "not a media" > "${src}/file.txt"
+
+# strip colors
+nocolor() {
+  sed -e 's/\x1b\[[0-9;]*m//g'
+}
+
+for catalog in "${tmpd}/catalog.json" "${tmpd}/catalog.sqlite"; do
+  echo ">>> testing with \"$(basename "${catalog}")\" <<<"
+
+  echo ">>> test no metadata by default <<<"
+  "${bin}" index -c "${catalog}" "${src}" media
+  "${bin}" ls -l -c "${catalog}" "media" | nocolor > "${out}"
+  cat_file "${out}"
+  grep -q 'meta\.' "${out}" && echo "metadata extracted without --metadata" && exit 1
+
+  echo ">>> test extraction <<<"
+  "${bin}" index --metadata -f -c "${catalog}" "${src}" media
+  "${bin}" ls -l -c "${catalog}" "media" | nocolor > "${out}"
+  cat_file "${out}"
+  grep -q '^photo.jpg .*meta.camera:Canon EOS 5D meta.gps:46.500000,6.600000 meta.height:480 meta.taken:2021-06-15 10:30:00 meta.width:640' "${out}" || (echo "bad jpeg metadata" && exit 1)
+  grep -q '^song.mp3 .*meta.album:Power, Corruption & Lies meta.artist:New Order meta.title:Blue Monday meta.year:1983' "${out}" || (echo "bad mp3 metadata" && exit 1)
+  grep -q '^piano.flac .*meta.artist:Debussy meta.duration:120 meta.title:Clair de lune meta.year:1905' "${out}" || (echo "bad flac metadata" && exit 1)
+  grep -q '^movie.mp4 .*meta.duration:90 meta.height:1080 meta.width:1920' "${out}" || (echo "bad mp4 metadata" && exit 1)
+  grep -q '^report.pdf .*meta.author:Joe meta.pages:2 meta.title:Annual (draft) report' "${out}" || (echo "bad pdf metadata" && exit 1)
+  grep -q '^file.txt .*meta\.' "${out}" && echo "metadata on text file" && exit 1
+
+  echo ">>> test find <<<"
+  "${bin}" find -c "${catalog}" -q 'meta.camera~Canon' | nocolor > "${out}"
+  [ "$(wc -l < "${out}")" != "1" ] && echo "expecting 1 camera entry" && exit 1
+  grep -q '^media/photo.jpg' "${out}" || (echo "camera not found" && exit 1)
+  "${bin}" find -c "${catalog}" -q 'meta.duration>=100' | nocolor > "${out}"
+  [ "$(wc -l < "${out}")" != "1" ] && echo "expecting 1 long entry" && exit 1
+  grep -q '^media/piano.flac' "${out}" || (echo "duration not found" && exit 1)
+  "${bin}" find -c "${catalog}" -q 'meta.duration<100' | nocolor > "${out}"
+  grep -q '^media/movie.mp4' "${out}" || (echo "short duration not found" && exit 1)
+  "${bin}" find -c "${catalog}" -q 'meta.pages=2 and meta.title~draft' | nocolor > "${out}"
+  grep -q '^media/report.pdf' "${out}" || (echo "pdf not found" && exit 1)
+  "${bin}" find -c "${catalog}" -q 'meta.artist="New Order"' | nocolor > "${out}"
+  grep -q '^media/song.mp3' "${out}" || (echo "artist not found" && exit 1)
+  "${bin}" find -c "${catalog}" -q 'meta.duration>long' && echo "non numeric value should fail" && exit 1
+
+  echo ">>> test json <<<"
+  "${bin}" ls -c "${catalog}" -f ndjson "media/photo.jpg" | grep -q '"metadata":{' || (echo "no metadata in json" && exit 1)
+
+  echo ">>> test incremental keeps metadata <<<"
+  "${bin}" index --metadata -u -f -c "${catalog}" "${src}" media
+  "${bin}" find -c "${catalog}" -q 'meta.camera~Canon' | nocolor > "${out}"
+  grep -q '^media/photo.jpg' "${out}" || (echo "metadata lost" && exit 1)
+
+  echo ">>> test re-index without metadata <<<"
+  # like checksums, only the metadata of the files that changed are dropped
+  echo "more" >> "${src}/report.pdf"
+  "${bin}" index -f -c "${catalog}" "${src}" media
+  "${bin}" ls -l -c "${catalog}" "media" | nocolor > "${out}"
+  cat_file "${out}"
+  grep -q '^report.pdf .*meta\.' "${out}" && echo "metadata not removed" && exit 1
+  grep -q '^photo.jpg .*meta.camera' "${out}" || (echo "metadata of unchanged file lost" && exit 1)
+
+  # reset for the next catalog
+  rm -r "${src}"
+  "${cur}/media.py" "${src}"
+  echo "not a media" > "${src}/file.txt"
+done
+
+echo "test $(basename "${0}") OK!"
+exit 0
@@ -0,0 +1,88 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test find query
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+catalog="${tmpd}/catalog"
+out="${tmpd}/output.txt"
+src1="${tmpd}/drive1"
+src2="${tmpd}/drive2"
+mkdir -p "${src1}/sub" "${src2}"
+head -c 3000 /dev/zero > "${src1}/big.bin"
+echo "small" > "${src1}/small.txt"
+echo "old" > "${src1}/sub/old.txt"
+touch -d 2019-05-01 "${src1}/sub/old.txt"
+printf '\x89PNG\r\n\x1a\n0000000000000000' > "${src2}/image.png"
+
+"${bin}" index -C -c "${catalog}" "${src1}" drive1 --tag offsite
+"${bin}" index -C -c "${catalog}" "${src2}" drive2
+
+# $1: query, $2: expected names (space separated)
+check_query()
+{
+  "${bin}" -c "${catalog}" find --format csv --query "${1}" | cut -d, -f1 | sort | xargs > "${out}"
+  echo "query \"${1}\": $(cat "${out}")"
+  [ "$(cat "${out}")" != "${2}" ] && echo "expecting \"${2}\"" && exit 1
+  return 0
+}
+
+echo ">>> test query <<<"
+check_query 'size>2K' "big.bin"
+check_query 'size>=3000 and size<=3000' "big.bin"
+check_query 'type=file and size<1k' "image.png old.txt small.txt"
+check_query 'type=dir' "sub"
+check_query 'maccess<2020-01-01' "old.txt"
+check_query 'maccess=2019-05' "old.txt"
+check_query 'mime~image/' "image.png"
+check_query 'storage.tag=offsite and type=file' "big.bin old.txt small.txt"
+check_query 'storage!=drive1' "image.png"
+check_query 'not storage.tag=offsite' "image.png"
+check_query 'txt and (size>5 or path~^sub/)' "old.txt small.txt"
+check_query 'name="small.txt" or name=big.bin' "big.bin small.txt"
+check_query 'type=file and checksum!~^md5:' ""
+
+echo ">>> test query with pattern <<<"
+"${bin}" -c "${catalog}" find --format csv --query 'type=file' 'small' > "${out}"
+cat_file "${out}"
+cnt=$(wc -l "${out}" | awk '{print $1}')
+[ "${cnt}" != "1" ] && echo "expecting 1 entry (got ${cnt})" && exit 1
+
+echo ">>> test query as argument <<<"
+"${bin}" -c "${catalog}" find --format csv 'size>2K and type=file' | cut -d, -f1 > "${out}"
+cat_file "${out}"
+[ "$(cat "${out}")" != "big.bin" ] && echo "query argument not honored" && exit 1
+"${bin}" -c "${catalog}" find --format csv 'storage.tag=offsite' 'small' | cut -d, -f1 > "${out}"
+cat_file "${out}"
+[ "$(cat "${out}")" != "small.txt" ] && echo "query argument with pattern not honored" && exit 1
+"${bin}" -c "${catalog}" find --format csv --query 'type=file' 'path~^sub/' | cut -d, -f1 > "${out}"
+cat_file "${out}"
+[ "$(cat "${out}")" != "old.txt" ] && echo "query argument and option not combined" && exit 1
+# a simple pattern is not a query
+"${bin}" -c "${catalog}" find --format csv 'txt' | cut -d, -f1 | sort | xargs > "${out}"
+cat_file "${out}"
+[ "$(cat "${out}")" != "old.txt small.txt" ] && echo "bad pattern" && exit 1
+
+echo ">>> test bad queries <<<"
+for q in 'size>1X' 'foo=bar' '(size>1' 'name<a' 'maccess=yesterday' 'size>1 and'; do
+  "${bin}" -c "${catalog}" find --query "${q}" > /dev/null 2>&1 && echo "\"${q}\" should fail" && exit 1
+done
+
+echo "test $(basename "${0}") OK!"
+exit 0
@@ -0,0 +1,64 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test catalog schema migration
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+catalog="${tmpd}/catalog.json"
+out="${tmpd}/output.txt"
+
+echo ">>> test schema <<<"
+"${bin}" index -c "${catalog}" "${cur}/../internal" internal
+grep '"schema": 6' "${catalog}" || (echo "no schema in catalog" && exit 1)
+
+echo ">>> test legacy catalog <<<"
+# catalogs created before schema versioning
+sed -i '/"schema":/d' "${catalog}"
+"${bin}" -c "${catalog}" ls > "${out}"
+cat_file "${out}"
+grep 'internal' "${out}" || (echo "legacy catalog not loaded" && exit 1)
+
+"${bin}" -c "${catalog}" catalog upgrade > "${out}"
+cat_file "${out}"
+grep 'schema 0 -> 1' "${out}" || (echo "no migration applied" && exit 1)
+grep '"schema": 6' "${catalog}" || (echo "catalog not upgraded" && exit 1)
+"${bin}" -c "${catalog}" catalog upgrade > "${out}"
+grep 'up to date' "${out}" || (echo "catalog upgraded twice" && exit 1)
+
+echo ">>> test newer catalog <<<"
+sed -i 's/"schema": 6/"schema": 999/' "${catalog}"
+cp "${catalog}" "${tmpd}/before"
+"${bin}" -c "${catalog}" ls && (echo "newer catalog loaded" && exit 1)
+"${bin}" -c "${catalog}" index "${cur}/../tests-ng" testsng && (echo "newer catalog indexed" && exit 1)
+diff "${catalog}" "${tmpd}/before" || (echo "newer catalog modified" && exit 1)
+
+if command -v sqlite3 >/dev/null 2>&1; then
+  echo ">>> test legacy sqlite catalog <<<"
+  catalog="${tmpd}/catalog.sqlite"
+  "${bin}" index -c "${catalog}" "${cur}/../internal" internal
+  sqlite3 "${catalog}" "DELETE FROM info WHERE key = 'schema'"
+  "${bin}" -c "${catalog}" ls internal > "${out}"
+  cat_file "${out}"
+  grep 'catalog' "${out}" || (echo "legacy sqlite catalog not loaded" && exit 1)
+  "${bin}" -c "${catalog}" catalog upgrade | grep 'schema 0 -> 1' || (echo "no migration applied" && exit 1)
+  [ "$(sqlite3 "${catalog}" "SELECT value FROM info WHERE key = 'schema'")" != "6" ] && echo "sqlite not upgraded" && exit 1
+fi
+
+echo "test $(basename "${0}") OK!"
+exit 0
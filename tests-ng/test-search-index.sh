@@ -0,0 +1,72 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test search index
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+catalog="${tmpd}/catalog"
+out="${tmpd}/output.txt"
+expected="${tmpd}/expected.txt"
+src1="${tmpd}/drive1"
+src2="${tmpd}/drive2"
+mkdir -p "${src1}/sub/deep" "${src2}"
+for f in alpha.txt beta.txt sub/alphabet.md sub/deep/alpha2.txt .hidden-alpha; do
+  echo "${f}" > "${src1}/${f}"
+done
+echo "other" > "${src2}/alpha-other"
+
+echo ">>> test search index creation <<<"
+"${bin}" index -c "${catalog}" "${src1}" drive1 --search-index
+[ ! -e "${catalog}.idx" ] && echo "search index not created" && exit 1
+# the index is kept up to date once it exists
+"${bin}" index -c "${catalog}" "${src2}" drive2
+
+echo ">>> test find with search index <<<"
+for patt in alpha 'alp*txt' 'sub' 'a'; do
+  rm -f "${catalog}.idx.bak"
+  mv "${catalog}.idx" "${catalog}.idx.bak"
+  "${bin}" -c "${catalog}" find --format csv "${patt}" > "${expected}"
+  mv "${catalog}.idx.bak" "${catalog}.idx"
+  "${bin}" -c "${catalog}" find --format csv "${patt}" > "${out}"
+  diff "${expected}" "${out}" || (echo "different results for \"${patt}\"" && exit 1)
+done
+"${bin}" -c "${catalog}" -d find --format csv alpha 2>&1 | grep 'with the search index' || (echo "search index not used" && exit 1)
+cnt=$("${bin}" -c "${catalog}" find --format csv alpha | wc -l)
+[ "${cnt}" != "5" ] && echo "expecting 5 entries (got ${cnt})" && exit 1
+
+echo ">>> test find with start path <<<"
+"${bin}" -c "${catalog}" find --format csv -p drive1/sub alpha > "${out}"
+cat_file "${out}"
+cnt=$(wc -l "${out}" | awk '{print $1}')
+[ "${cnt}" != "2" ] && echo "expecting 2 entries (got ${cnt})" && exit 1
+
+echo ">>> test stale search index <<<"
+"${bin}" -c "${catalog}" storage rm drive2 --force
+"${bin}" -c "${catalog}" -d find --format csv alpha > "${out}" 2>&1
+grep 'search index is stale' "${out}" || (echo "stale index used" && exit 1)
+grep 'alpha-other' "${out}" && (echo "removed storage found" && exit 1)
+
+echo ">>> test search index rebuild <<<"
+"${bin}" -c "${catalog}" catalog search-index
+"${bin}" -c "${catalog}" -d find --format csv alpha 2>&1 | grep 'with the search index' || (echo "search index not used" && exit 1)
+"${bin}" -c "${catalog}" catalog search-index --remove
+[ -e "${catalog}.idx" ] && echo "search index not removed" && exit 1
+
+echo "test $(basename "${0}") OK!"
+exit 0
@@ -0,0 +1,99 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test serve command
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+catalog="${tmpd}/catalog"
+src="${tmpd}/src"
+out="${tmpd}/output.txt"
+port=$((20000 + RANDOM % 10000))
+url="http://127.0.0.1:${port}"
+
+mkdir -p "${src}/dir/sub"
+echo "abc" > "${src}/dir/a.txt"
+echo "defgh" > "${src}/dir/sub/b.txt"
+echo "hidden" > "${src}/dir/.hidden"
+
+"${bin}" index -a -C -c "${catalog}" "${src}" mystorage
+[ ! -e "${catalog}" ] && echo "catalog not created" && exit 1
+
+# start the server
+"${bin}" serve -c "${catalog}" -a "127.0.0.1:${port}" -r 200ms &
+pid=$!
+trap 'kill ${pid} 2>/dev/null || true; on_exit' EXIT
+for _ in $(seq 50); do
+  curl -s "${url}/api/info" >/dev/null && break
+  sleep 0.1
+done
+
+# check_json <url> <python condition on content>
+check_json() {
+  curl -s "${1}" > "${out}"
+  cat "${out}"
+  python3 - "${out}" "${2}" <<'PYEOF'
+import json, sys
+with open(sys.argv[1]) as f:
+    content = json.load(f)
+if not eval(sys.argv[2]):
+    print(f"condition failed: {sys.argv[2]}")
+    sys.exit(1)
+PYEOF
+}
+
+echo ">>> test storages <<<"
+check_json "${url}/api/storages" "content[0]['name'] == 'mystorage' and content[0]['attrs']['nbfiles'] == '3'"
+
+echo ">>> test ls <<<"
+check_json "${url}/api/ls?path=mystorage/dir" "sorted(c['name'] for c in content[0]['children']) == ['a.txt', 'sub']"
+check_json "${url}/api/ls?path=mystorage/dir&all=true" "len(content[0]['children']) == 3"
+
+echo ">>> test node <<<"
+check_json "${url}/api/node?path=mystorage/dir/sub/b.txt" "content[0]['size'] == 6 and content[0]['attrs']['checksum'].startswith('md5:')"
+
+echo ">>> test find <<<"
+check_json "${url}/api/find?pattern=txt" "sorted(e['path'] for e in content['entries']) == ['mystorage/dir/a.txt', 'mystorage/dir/sub/b.txt']"
+check_json "${url}/api/find?query=name~txt%20and%20size>5" "[e['name'] for e in content['entries']] == ['b.txt']"
+check_json "${url}/api/find?pattern=txt&limit=1" "len(content['entries']) == 1 and content['truncated']"
+
+echo ">>> test du <<<"
+check_json "${url}/api/du" "content[-1]['name'] == 'mystorage' and len(content) == 3"
+
+echo ">>> test errors <<<"
+code=$(curl -s -o /dev/null -w '%{http_code}' "${url}/api/ls?path=doesnotexist")
+[ "${code}" != "404" ] && echo "expecting 404 (${code})" && exit 1
+code=$(curl -s -o /dev/null -w '%{http_code}' "${url}/api/find?query=foo>")
+[ "${code}" != "400" ] && echo "expecting 400 (${code})" && exit 1
+code=$(curl -s -o /dev/null -w '%{http_code}' -X POST "${url}/api/ls")
+[ "${code}" != "405" ] && echo "expecting 405 (${code})" && exit 1
+
+echo ">>> test ui <<<"
+curl -s "${url}/" > "${out}"
+grep -q 'app.js' "${out}" || (echo "no ui" && exit 1)
+curl -s "${url}/app.js" > "${out}"
+grep -q 'api/' "${out}" || (echo "no app.js" && exit 1)
+
+echo ">>> test reload <<<"
+echo "new" > "${src}/new.txt"
+"${bin}" index -a -C -f -c "${catalog}" "${src}" mystorage
+sleep 1
+check_json "${url}/api/find?pattern=new" "[e['path'] for e in content['entries']] == ['mystorage/new.txt']"
+
+echo "test $(basename "${0}") OK!"
+exit 0
@@ -0,0 +1,73 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test sqlite catalog backend
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+catalog="${tmpd}/catalog.sqlite"
+out="${tmpd}/output.txt"
+
+# index
+echo ">>> test index <<<"
+"${bin}" index -a -C -c "${catalog}" "${cur}/../internal" internal
+[ ! -e "${catalog}" ] && echo "catalog not created" && exit 1
+"${bin}" index -a -C -c "${catalog}" "${cur}/../tests-ng" testsng
+
+echo ">>> test find <<<"
+"${bin}" find -c "${catalog}" | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+expected=$(find "${cur}/../internal" "${cur}/../tests-ng" ! -path '*/.git/*' | wc -l)
+cnt=$(wc -l "${out}" | awk '{print $1}')
+[ "${cnt}" != "${expected}" ] && echo "expecting ${expected} lines (${cnt})" && exit 1
+
+echo ">>> test tag <<<"
+"${bin}" -c "${catalog}" storage tag testsng tag1 | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+cat_file "${out}"
+"${bin}" -c "${catalog}" storage list | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+grep "tags:tag1" "${out}" || (echo "tag1 not saved" && exit 1)
+
+echo ">>> test re-index <<<"
+"${bin}" index -a -C -f -c "${catalog}" "${cur}/../internal" internal
+"${bin}" find -c "${catalog}" | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+cnt=$(wc -l "${out}" | awk '{print $1}')
+[ "${cnt}" != "${expected}" ] && echo "expecting ${expected} lines (${cnt})" && exit 1
+
+echo ">>> test rm storage <<<"
+"${bin}" -c "${catalog}" storage rm -f testsng
+"${bin}" find -c "${catalog}" | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+expected=$(find "${cur}/../internal" ! -path '*/.git/*' | wc -l)
+cnt=$(wc -l "${out}" | awk '{print $1}')
+[ "${cnt}" != "${expected}" ] && echo "expecting ${expected} lines (${cnt})" && exit 1
+
+echo ">>> test compare with json <<<"
+"${bin}" index -a -C -c "${tmpd}/catalog.json" "${cur}/../internal" internal
+"${bin}" ls -r -a -c "${tmpd}/catalog.json" | sed -e 's/\x1b\[[0-9;]*m//g' > "${tmpd}/json.txt"
+"${bin}" ls -r -a -c "${catalog}" | sed -e 's/\x1b\[[0-9;]*m//g' > "${out}"
+diff "${tmpd}/json.txt" "${out}" || (echo "sqlite and json differ" && exit 1)
+
+echo ">>> test lazy loading <<<"
+"${bin}" du -S -c "${tmpd}/catalog.json" internal/catalog > "${tmpd}/json.txt"
+"${bin}" du -S -c "${catalog}" internal/catalog > "${out}"
+diff "${tmpd}/json.txt" "${out}" || (echo "sqlite and json du differ" && exit 1)
+"${bin}" ls -r -c "${tmpd}/catalog.json" 'internal/walker/*' | sed -e 's/\x1b\[[0-9;]*m//g' | sort > "${tmpd}/json.txt"
+"${bin}" ls -r -c "${catalog}" 'internal/walker/*' | sed -e 's/\x1b\[[0-9;]*m//g' | sort > "${out}"
+cat_file "${out}"
+diff "${tmpd}/json.txt" "${out}" || (echo "sqlite and json ls differ" && exit 1)
+
+echo "test $(basename "${0}") OK!"
+exit 0
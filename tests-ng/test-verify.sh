@@ -0,0 +1,78 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test verify command
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+catalog="${tmpd}/catalog"
+out="${tmpd}/output.txt"
+src="${tmpd}/drive"
+mkdir -p "${src}/sub"
+echo "a" > "${src}/a"
+echo "b" > "${src}/sub/b"
+echo "c" > "${src}/c"
+echo "d" > "${src}/d"
+
+"${bin}" index -C -c "${catalog}" "${src}" drive
+cp "${catalog}" "${tmpd}/catalog.orig"
+
+echo ">>> test verify unchanged <<<"
+"${bin}" -c "${catalog}" verify --nocolor drive "${src}"
+
+echo ">>> test verify changes <<<"
+rm "${src}/c"
+echo "new" > "${src}/new"
+echo "more content" >> "${src}/a"
+# same size and modification time, different content
+mtime=$(stat -c %Y "${src}/sub/b")
+echo "z" > "${src}/sub/b"
+touch -d "@${mtime}" "${src}/sub/b"
+chmod 600 "${src}/d"
+
+set +e
+"${bin}" -c "${catalog}" verify --nocolor drive "${src}" > "${out}"
+ret="$?"
+set -e
+cat_file "${out}"
+[ "${ret}" = "0" ] && echo "verify should fail" && exit 1
+grep 'missing *drive/c$' "${out}" || (echo "missing not found" && exit 1)
+grep 'new *drive/new$' "${out}" || (echo "new not found" && exit 1)
+grep 'modified *drive/a (size' "${out}" || (echo "modified not found" && exit 1)
+grep 'modified *drive/d (mode)' "${out}" || (echo "mode not found" && exit 1)
+grep 'corrupted *drive/sub/b (checksum)' "${out}" || (echo "corrupted not found" && exit 1)
+
+echo ">>> test verify quick <<<"
+set +e
+"${bin}" -c "${catalog}" verify --nocolor --quick drive "${src}" > "${out}"
+set -e
+cat_file "${out}"
+grep 'drive/sub/b' "${out}" && (echo "checksum verified" && exit 1)
+
+echo ">>> test verify ignore <<<"
+set +e
+"${bin}" -c "${catalog}" verify --nocolor -i "*new" drive "${src}" > "${out}"
+set -e
+cat_file "${out}"
+grep 'new *drive/new' "${out}" && (echo "ignored file reported" && exit 1)
+
+echo ">>> test catalog untouched <<<"
+diff "${catalog}" "${tmpd}/catalog.orig" || (echo "catalog modified" && exit 1)
+
+echo "test $(basename "${0}") OK!"
+exit 0
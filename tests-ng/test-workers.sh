@@ -0,0 +1,49 @@
+#!/usr/bin/env bash
+# author: deadc0de6 (https://github.com/deadc0de6)
+# Copyright (c) 2024, deadc0de6
+#
+# test parallel indexing
+#
+
+## start-test-cookie
+set -eu -o errtrace -o pipefail
+cur=$(cd "$(dirname "${0}")" && pwd)
+bin="${cur}/../bin/gocatcli"
+[ ! -e "${bin}" ] && echo "\"${bin}\" not found" && exit 1
+# shellcheck disable=SC1091
+source "${cur}"/helpers
+## end-test-cookie
+
+######################################
+## the test
+
+tmpd=$(mktemp -d --suffix='-dotdrop-tests' || mktemp -d)
+clear_on_exit "${tmpd}"
+
+serial="${tmpd}/serial.catalog"
+parallel="${tmpd}/parallel.catalog"
+out1="${tmpd}/output1.txt"
+out2="${tmpd}/output2.txt"
+
+# archives to index
+arcdir="${tmpd}/src"
+mkdir -p "${arcdir}"
+cp -r "${cur}/../internal" "${arcdir}/"
+tar -C "${cur}/.." -czf "${arcdir}/archive.tar.gz" "internal"
+
+echo ">>> test parallel index <<<"
+"${bin}" index -C -a -w 1 -c "${serial}" "${arcdir}" src
+"${bin}" index -C -a -w 8 -c "${parallel}" "${arcdir}" src
+
+# drop the indexed date and free space
+"${bin}" -c "${serial}" ls -r -a --format csv | cut -d, -f1-4,6-8,10- > "${out1}"
+"${bin}" -c "${parallel}" ls -r -a --format csv | cut -d, -f1-4,6-8,10- > "${out2}"
+cat_file "${out2}"
+diff "${out1}" "${out2}" || (echo "parallel index differs" && exit 1)
+
+# checksums were computed
+cnt=$(grep -c ',md5:[0-9a-f]\{32\},' "${out2}")
+[ "${cnt}" = "0" ] && echo "no checksum" && exit 1
+
+echo "test $(basename "${0}") OK!"
+exit 0